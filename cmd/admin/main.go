@@ -0,0 +1,112 @@
+// Command admin runs maintenance tasks against the database.
+//
+//	admin up           apply all pending migrations
+//	admin down [n]     revert the last n migrations (default 1)
+//	admin status       list migrations and when they were applied
+//	admin seed         load the sample data
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+
+	"github.com/BuildFrom/Golang-Stdlib/internal/infrastructure/sqldb"
+)
+
+const usage = `usage: admin <command>
+
+commands:
+  up          apply all pending migrations
+  down [n]    revert the last n migrations (default 1)
+  status      list migrations and when they were applied
+  seed        load the sample data`
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		log.Fatalf("admin: %v", err)
+	}
+}
+
+func run(args []string) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	cfg, err := sqldb.ConfigFromEnv()
+	if err != nil {
+		return err
+	}
+
+	db, err := sqldb.Open(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	switch cmd := args[0]; cmd {
+	case "up":
+		applied, err := sqldb.MigrateUp(ctx, db)
+		for _, m := range applied {
+			fmt.Printf("applied  %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("nothing to migrate")
+		}
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("down: invalid step count %q", args[1])
+			}
+		}
+
+		reverted, err := sqldb.MigrateDown(ctx, db, steps)
+		for _, m := range reverted {
+			fmt.Printf("reverted %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(reverted) == 0 {
+			fmt.Println("nothing to revert")
+		}
+
+	case "status":
+		states, err := sqldb.MigrationStatus(ctx, db)
+		if err != nil {
+			return err
+		}
+		for _, s := range states {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%-30s %s\n", s.Version, s.Name, applied)
+		}
+
+	case "seed":
+		if err := sqldb.Seed(ctx, db); err != nil {
+			return err
+		}
+		fmt.Println("seeded")
+
+	default:
+		return fmt.Errorf("unknown command %q\n\n%s", cmd, usage)
+	}
+
+	return nil
+}
@@ -1,21 +1,54 @@
 package all
 
 import (
+	"expvar"
+	"log/slog"
 	"net/http"
 
 	"github.com/BuildFrom/Golang-Stdlib/internal/app/healthapp"
 	"github.com/BuildFrom/Golang-Stdlib/internal/app/helloapp"
 	"github.com/BuildFrom/Golang-Stdlib/internal/app/todoapp"
 	"github.com/BuildFrom/Golang-Stdlib/internal/infrastructure/sqldb"
+	"github.com/BuildFrom/Golang-Stdlib/internal/sdk/health"
+	mw "github.com/BuildFrom/Golang-Stdlib/internal/sdk/middleware"
+	"github.com/BuildFrom/Golang-Stdlib/internal/sdk/web"
 )
 
-// RegisterRoutes registers all routes for the API.
-func RegisterRoutes(dbService sqldb.Service) http.Handler {
+// minFreeDisk is the share of free disk space below which the service
+// reports itself as degraded.
+const minFreeDisk = 0.1
+
+// RegisterRoutes registers all routes for the API and adds the health checks
+// of its dependencies to checks. The returned handler wraps the mux in the
+// global middleware, which sees every request before it is routed. Each app
+// gets its own web.App for middleware that only applies to its routes.
+func RegisterRoutes(log *slog.Logger, dbService sqldb.Service, checks *health.Registry, cors mw.CORSConfig) http.Handler {
 	mux := http.NewServeMux()
 
-	helloapp.RegisterRoutes(mux)
-	healthapp.RegisterRoutes(mux, dbService)
-	todoapp.RegisterRoutes(mux, dbService)
+	sqldb.RegisterChecks(checks, dbService)
+	checks.Register("disk", health.DiskCheck(".", minFreeDisk))
+
+	helloapp.RegisterRoutes(web.NewApp(log, mux))
+	healthapp.RegisterRoutes(web.NewApp(log, mux), checks)
+	todoapp.RegisterRoutes(web.NewApp(log, mux), dbService)
+
+	global := []mw.Middleware{
+		mw.RequestID(),
+		mw.Logger(log),
+		mw.Recover(log),
+		mw.CORS(cors, mux),
+	}
+
+	return mw.WrapMiddleware(mux, global...)
+}
+
+// DebugMux returns the handler for the debug listener, which serves the
+// process and query metrics, including the sqldb query histograms, at
+// /debug/vars. It exposes the command line and memory statistics, so it
+// must not be reachable from outside.
+func DebugMux() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("GET /debug/vars", expvar.Handler())
 
 	return mux
 }
@@ -0,0 +1,97 @@
+package all
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/BuildFrom/Golang-Stdlib/internal/sdk/health"
+	mw "github.com/BuildFrom/Golang-Stdlib/internal/sdk/middleware"
+)
+
+func Test_RegisterRoutes(t *testing.T) {
+	t.Parallel()
+
+	cors := mw.DefaultCORSConfig()
+	cors.AllowedOrigins = []string{"https://app.example.com"}
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	// None of the requests below reach the database.
+	h := RegisterRoutes(log, nil, health.NewRegistry(health.DefaultTimeout), cors)
+
+	tests := []struct {
+		name          string
+		method        string
+		target        string
+		origin        string
+		requestMethod string
+		status        int
+		allowOrigin   string
+	}{
+		{"Route", http.MethodGet, "/liveness", "https://app.example.com", "", http.StatusOK, "https://app.example.com"},
+		{"Hello", http.MethodGet, "/hello", "https://app.example.com", "", http.StatusOK, "https://app.example.com"},
+		{"UnknownRoute", http.MethodGet, "/nope/x", "https://app.example.com", "", http.StatusNotFound, "https://app.example.com"},
+		{"OtherOrigin", http.MethodGet, "/liveness", "https://evil.example.com", "", http.StatusOK, ""},
+		{"Preflight", http.MethodOptions, "/todo/7", "https://app.example.com", http.MethodPatch, http.StatusNoContent, "https://app.example.com"},
+		{"PreflightUnknownRoute", http.MethodOptions, "/nope/x", "https://app.example.com", http.MethodDelete, http.StatusNotFound, "https://app.example.com"},
+		{"PreflightHealthMethod", http.MethodOptions, "/liveness", "https://app.example.com", http.MethodDelete, http.StatusMethodNotAllowed, "https://app.example.com"},
+		{"PreflightTodoMethod", http.MethodOptions, "/todo/1/archive", "https://app.example.com", http.MethodPut, http.StatusMethodNotAllowed, "https://app.example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(tt.method, tt.target, nil)
+			r.Header.Set("Origin", tt.origin)
+			if tt.requestMethod != "" {
+				r.Header.Set("Access-Control-Request-Method", tt.requestMethod)
+			}
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if w.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, w.Code)
+			}
+
+			// The global middleware runs for every request, routed or not.
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.allowOrigin {
+				t.Errorf("Expected allowed origin %q, got %q", tt.allowOrigin, got)
+			}
+		})
+	}
+}
+
+func Test_DebugMux(t *testing.T) {
+	t.Parallel()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	api := RegisterRoutes(log, nil, health.NewRegistry(health.DefaultTimeout), mw.DefaultCORSConfig())
+
+	tests := []struct {
+		name     string
+		handler  http.Handler
+		expected bool
+	}{
+		{"API", api, false},
+		{"Debug", DebugMux(), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			w := httptest.NewRecorder()
+			tt.handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/vars", nil))
+
+			if got := strings.Contains(w.Body.String(), `"memstats"`); got != tt.expected {
+				t.Errorf("Expected metrics served to be %v, got %v with status %d", tt.expected, got, w.Code)
+			}
+		})
+	}
+}
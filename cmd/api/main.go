@@ -9,10 +9,15 @@ import (
 	"os"
 	"os/signal"
 	"runtime"
+	"strconv"
 	"syscall"
 	"time"
 
+	"github.com/BuildFrom/Golang-Stdlib/cmd/api/build/all"
 	"github.com/BuildFrom/Golang-Stdlib/internal/infrastructure/server"
+	"github.com/BuildFrom/Golang-Stdlib/internal/infrastructure/sqldb"
+	"github.com/BuildFrom/Golang-Stdlib/internal/sdk/health"
+	mw "github.com/BuildFrom/Golang-Stdlib/internal/sdk/middleware"
 )
 
 func main() {
@@ -23,22 +28,70 @@ func main() {
 
 func run() error {
 
-	log := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	// LOG_FORMAT selects json or text output, LOG_LEVEL the minimum level.
+	log := mw.LoggerFromEnv()
 
 	log.Info("GOMAXPROCS", "cpu", runtime.GOMAXPROCS(0))
 
-	server := server.NewServer()
+	dbConfig, err := sqldb.ConfigFromEnv()
+	if err != nil {
+		return err
+	}
+	dbConfig.Logger = log
+
+	db, err := sqldb.Open(dbConfig)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Set MIGRATE_ON_BOOT=true to bring the schema up to date before
+	// serving. The migration lock makes this safe with several replicas.
+	if migrate, _ := strconv.ParseBool(os.Getenv("MIGRATE_ON_BOOT")); migrate {
+		applied, err := sqldb.MigrateUp(context.Background(), db)
+		if err != nil {
+			return err
+		}
+		log.Info("Migrations applied", "count", len(applied))
+	}
+
+	corsConfig, err := mw.CORSConfigFromEnv()
+	if err != nil {
+		return err
+	}
+
+	checks := health.NewRegistry(health.DefaultTimeout)
+
+	server := server.NewServer(log, db, checks, corsConfig)
 
 	// Create a done channel to signal when the shutdown is complete
 	done := make(chan bool, 1)
 
 	// Run graceful shutdown in a separate goroutine
-	go gracefulShutdown(server, done)
+	go gracefulShutdown(log, server, checks, drainDelay(), done)
+
+	// DEBUG_ADDR, such as localhost:4000, serves the metrics on a listener
+	// of its own. It is off when unset.
+	if addr := os.Getenv("DEBUG_ADDR"); addr != "" {
+		go func() {
+			log.Info("Starting debug server", "addr", addr)
+
+			debug := http.Server{
+				Addr:              addr,
+				Handler:           all.DebugMux(),
+				ReadHeaderTimeout: 10 * time.Second,
+				ErrorLog:          slog.NewLogLogger(log.Handler(), slog.LevelError),
+			}
+			if err := debug.ListenAndServe(); err != nil {
+				log.Error("debug server error", "error", err)
+			}
+		}()
+	}
 
 	// Start the server
 	log.Info("Starting server", "port", server.Addr)
 
-	err := server.ListenAndServe()
+	err = server.ListenAndServe()
 	if err != nil && err != http.ErrServerClosed {
 		panic(fmt.Sprintf("http server error: %s", err))
 	}
@@ -50,7 +103,7 @@ func run() error {
 	return nil
 }
 
-func gracefulShutdown(apiServer *http.Server, done chan bool) {
+func gracefulShutdown(log *slog.Logger, apiServer *http.Server, checks *health.Registry, delay time.Duration, done chan bool) {
 	// Create context that listens for the interrupt signal from the OS.
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
@@ -58,18 +111,37 @@ func gracefulShutdown(apiServer *http.Server, done chan bool) {
 	// Listen for the interrupt signal.
 	<-ctx.Done()
 
-	log.Println("shutting down gracefully, press Ctrl+C again to force")
+	// Restore the default behavior so a second signal kills the process.
+	stop()
+
+	log.Info("shutting down gracefully, press Ctrl+C again to force")
+
+	// Fail readiness first and give load balancers time to notice before
+	// the listener is closed.
+	checks.Drain()
+	log.Info("draining", "delay", delay)
+	time.Sleep(delay)
 
 	// The context is used to inform the server it has 5 seconds to finish
 	// the request it is currently handling
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	if err := apiServer.Shutdown(ctx); err != nil {
-		log.Printf("Server forced to shutdown with error: %v", err)
+		log.Error("Server forced to shutdown", "error", err)
 	}
 
-	log.Println("Server exiting")
+	log.Info("Server exiting")
 
 	// Notify the main goroutine that the shutdown is complete
 	done <- true
 }
+
+// drainDelay is how long readiness reports 503 before the server stops
+// accepting connections. It is read from SHUTDOWN_DRAIN_DELAY.
+func drainDelay() time.Duration {
+	d, err := time.ParseDuration(os.Getenv("SHUTDOWN_DRAIN_DELAY"))
+	if err != nil || d < 0 {
+		return 5 * time.Second
+	}
+	return d
+}
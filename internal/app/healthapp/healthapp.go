@@ -1,29 +1,68 @@
 package healthapp
 
 import (
-	"encoding/json"
-	"log"
 	"net/http"
+	"sync/atomic"
 
-	"github.com/BuildFrom/Golang-Stdlib/internal/infrastructure/sqldb"
+	"github.com/BuildFrom/Golang-Stdlib/internal/sdk/health"
+	"github.com/BuildFrom/Golang-Stdlib/internal/sdk/web"
 )
 
 type app struct {
-	db sqldb.Service
+	checks  *health.Registry
+	started atomic.Bool
 }
 
-func newApp(db sqldb.Service) *app {
-	return &app{db: db}
+func newApp(checks *health.Registry) *app {
+	return &app{checks: checks}
 }
 
-func (a *app) Health(w http.ResponseWriter, r *http.Request) {
-	jsonResp, err := json.Marshal(a.db.Health())
-	if err != nil {
-		log.Fatalf("error handling JSON marshal. Err: %v", err)
+// Liveness reports that the process is running. It never looks at
+// dependencies, so a database outage does not get the process restarted.
+func (a *app) Liveness(w http.ResponseWriter, r *http.Request) (web.Encoder, error) {
+	return health.Report{Status: health.StatusUp}, nil
+}
+
+// Readiness runs every registered check and answers 503 when one of them is
+// down or the server is shutting down. A degraded service still takes
+// traffic.
+func (a *app) Readiness(w http.ResponseWriter, r *http.Request) (web.Encoder, error) {
+	if a.checks.Draining() {
+		return web.NewResponse(http.StatusServiceUnavailable, draining), nil
+	}
+
+	report := a.checks.Run(r.Context())
+
+	return web.NewResponse(statusCode(report), report), nil
+}
+
+// Startup answers 503 until the checks have passed once and 200 from then
+// on, so slow starts are not mistaken for a dead process.
+func (a *app) Startup(w http.ResponseWriter, r *http.Request) (web.Encoder, error) {
+	if a.started.Load() {
+		return health.Report{Status: health.StatusUp}, nil
 	}
 
-	_, err = w.Write(jsonResp)
-	if err != nil {
-		log.Printf("error writing response. Err: %v", err)
+	report := a.checks.Run(r.Context())
+	if report.Status != health.StatusDown {
+		a.started.Store(true)
+	}
+
+	return web.NewResponse(statusCode(report), report), nil
+}
+
+// -----------------------------------------------------------------------------
+
+var draining = health.Report{
+	Status: health.StatusDown,
+	Checks: map[string]health.CheckResult{
+		"shutdown": {Result: health.Result{Status: health.StatusDown, Message: "server is shutting down"}},
+	},
+}
+
+func statusCode(report health.Report) int {
+	if report.Status == health.StatusDown {
+		return http.StatusServiceUnavailable
 	}
+	return http.StatusOK
 }
@@ -0,0 +1,72 @@
+package healthapp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/BuildFrom/Golang-Stdlib/internal/sdk/health"
+	"github.com/BuildFrom/Golang-Stdlib/internal/sdk/web"
+)
+
+func Test_Probes(t *testing.T) {
+	t.Parallel()
+
+	var dbErr error
+
+	checks := health.NewRegistry(0)
+	checks.Register("database", func(ctx context.Context) health.Result {
+		if dbErr != nil {
+			return health.Down(dbErr)
+		}
+		return health.Up(nil)
+	})
+
+	api := newApp(checks)
+
+	serve := func(h web.HandlerFunc) int {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		return w.Code
+	}
+
+	dbErr = errors.New("connection refused")
+
+	if code := serve(api.Liveness); code != http.StatusOK {
+		t.Errorf("Expected liveness %d while the database is down, got %d", http.StatusOK, code)
+	}
+	if code := serve(api.Readiness); code != http.StatusServiceUnavailable {
+		t.Errorf("Expected readiness %d while the database is down, got %d", http.StatusServiceUnavailable, code)
+	}
+	if code := serve(api.Startup); code != http.StatusServiceUnavailable {
+		t.Errorf("Expected startup %d before the first success, got %d", http.StatusServiceUnavailable, code)
+	}
+
+	dbErr = nil
+
+	if code := serve(api.Readiness); code != http.StatusOK {
+		t.Errorf("Expected readiness %d, got %d", http.StatusOK, code)
+	}
+	if code := serve(api.Startup); code != http.StatusOK {
+		t.Errorf("Expected startup %d, got %d", http.StatusOK, code)
+	}
+
+	// Once started, the startup probe no longer follows the dependencies.
+	dbErr = errors.New("connection refused")
+
+	if code := serve(api.Startup); code != http.StatusOK {
+		t.Errorf("Expected startup to stay %d, got %d", http.StatusOK, code)
+	}
+
+	dbErr = nil
+	checks.Drain()
+
+	if code := serve(api.Readiness); code != http.StatusServiceUnavailable {
+		t.Errorf("Expected readiness %d while draining, got %d", http.StatusServiceUnavailable, code)
+	}
+	if code := serve(api.Liveness); code != http.StatusOK {
+		t.Errorf("Expected liveness %d while draining, got %d", http.StatusOK, code)
+	}
+}
@@ -1,12 +1,15 @@
 package healthapp
 
 import (
-	"net/http"
-
-	"github.com/BuildFrom/Golang-Stdlib/internal/infrastructure/sqldb"
+	"github.com/BuildFrom/Golang-Stdlib/internal/sdk/health"
+	"github.com/BuildFrom/Golang-Stdlib/internal/sdk/web"
 )
 
-func RegisterRoutes(mux *http.ServeMux, dbService sqldb.Service) {
-	api := newApp(dbService)
-	mux.HandleFunc("/readiness", api.Health)
+// RegisterRoutes exposes the probes backed by the checks in the registry.
+// App packages add their own checks to the same registry.
+func RegisterRoutes(app *web.App, checks *health.Registry) {
+	api := newApp(checks)
+	app.HandleFunc("GET /liveness", api.Liveness)
+	app.HandleFunc("GET /readiness", api.Readiness)
+	app.HandleFunc("GET /startup", api.Startup)
 }
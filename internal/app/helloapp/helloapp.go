@@ -2,8 +2,9 @@ package helloapp
 
 import (
 	"encoding/json"
-	"log"
 	"net/http"
+
+	"github.com/BuildFrom/Golang-Stdlib/internal/sdk/web"
 )
 
 type app struct{}
@@ -14,16 +15,18 @@ func newApp() *app {
 	}
 }
 
-func (h *app) HelloWorld(w http.ResponseWriter, r *http.Request) {
-	resp := map[string]string{"message": "Hello World"}
+func (h *app) HelloWorld(w http.ResponseWriter, r *http.Request) (web.Encoder, error) {
+	return message{Message: "Hello World"}, nil
+}
 
-	jsonResp, err := json.Marshal(resp)
-	if err != nil {
-		log.Fatalf("error handling JSON marshal. Err: %v", err)
-	}
+// -----------------------------------------------------------------------------
 
-	_, err = w.Write(jsonResp)
-	if err != nil {
-		log.Printf("error writing response. Err: %v", err)
-	}
+type message struct {
+	Message string `json:"message"`
+}
+
+// Encode implements the encoder interface.
+func (m message) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(m)
+	return data, "application/json", err
 }
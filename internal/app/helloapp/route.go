@@ -1,10 +1,10 @@
 package helloapp
 
 import (
-	"net/http"
+	"github.com/BuildFrom/Golang-Stdlib/internal/sdk/web"
 )
 
-func RegisterRoutes(mux *http.ServeMux) {
+func RegisterRoutes(app *web.App) {
 	api := newApp()
-	mux.HandleFunc("/", api.HelloWorld)
+	app.HandleFunc("GET /hello", api.HelloWorld)
 }
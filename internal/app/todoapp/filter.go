@@ -0,0 +1,282 @@
+package todoapp
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BuildFrom/Golang-Stdlib/internal/sdk/errs"
+	"github.com/BuildFrom/Golang-Stdlib/internal/sdk/order"
+	"github.com/BuildFrom/Golang-Stdlib/internal/sdk/page"
+)
+
+// QueryFilter holds the available fields a query can be filtered on.
+// A nil field is not applied. Deleted selects soft deleted todos instead of
+// live ones.
+type QueryFilter struct {
+	Status         *Status
+	Archived       *bool
+	Deleted        bool
+	Title          *string
+	StartExpiresAt *time.Time
+	EndExpiresAt   *time.Time
+}
+
+// -----------------------------------------------------------------------------
+
+// orderByFields maps the field names clients may order by to the columns
+// they represent. Anything not listed here is rejected.
+var orderByFields = map[string]string{
+	"id":         "id",
+	"title":      "title",
+	"status":     "status",
+	"expired_at": "expires_at",
+	"created_at": "created_at",
+}
+
+// keysetField is the column the keyset cursor is based on, backed by
+// idx_todos_created.
+const keysetField = "created_at"
+
+var defaultOrderBy = order.NewBy(keysetField, order.ASC)
+
+// -----------------------------------------------------------------------------
+
+// cursor marks the last row of a page for keyset pagination on
+// (created_at, id).
+type cursor struct {
+	CreatedAt time.Time
+	ID        int
+}
+
+func newCursor(todo Todo) cursor {
+	return cursor{
+		CreatedAt: todo.CreatedAt,
+		ID:        todo.ID,
+	}
+}
+
+// String encodes the cursor into the opaque form handed to clients.
+func (c cursor) String() string {
+	raw := c.CreatedAt.Format(time.RFC3339Nano) + "|" + strconv.Itoa(c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func parseCursor(value string) (cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor{}, fmt.Errorf("malformed cursor")
+	}
+
+	createdAt, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return cursor{}, fmt.Errorf("malformed cursor")
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return cursor{}, fmt.Errorf("malformed cursor")
+	}
+
+	n, err := strconv.Atoi(id)
+	if err != nil {
+		return cursor{}, fmt.Errorf("malformed cursor")
+	}
+
+	return cursor{CreatedAt: t, ID: n}, nil
+}
+
+// -----------------------------------------------------------------------------
+
+// queryParams is the parsed form of the list endpoint query string.
+type queryParams struct {
+	filter  QueryFilter
+	orderBy order.By
+	page    page.Page
+	after   *cursor
+}
+
+func parseQueryParams(r *http.Request) (queryParams, error) {
+	values := r.URL.Query()
+
+	var fields errs.FieldErrors
+	addErr := func(field string, err error) {
+		fields = append(fields, errs.FieldError{Field: field, Err: err.Error()})
+	}
+
+	var qp queryParams
+
+	pg, err := page.Parse(values.Get("page"), values.Get("rows"))
+	if err != nil {
+		addErr("page", err)
+	}
+	qp.page = pg
+
+	orderBy, err := order.Parse(orderByFields, values.Get("orderBy"), defaultOrderBy)
+	if err != nil {
+		addErr("orderBy", err)
+	}
+	qp.orderBy = orderBy
+
+	if v := values.Get("cursor"); v != "" {
+		c, err := parseCursor(v)
+		switch {
+		case err != nil:
+			addErr("cursor", err)
+		case orderBy.Field != keysetField:
+			addErr("cursor", fmt.Errorf("cursor requires ordering by %s", keysetField))
+		default:
+			qp.after = &c
+		}
+	}
+
+	if v := values.Get("status"); v != "" {
+		st, err := Parse(v)
+		if err != nil {
+			addErr("status", err)
+		}
+		qp.filter.Status = &st
+	}
+
+	if v := values.Get("deleted"); v != "" {
+		deleted, err := strconv.ParseBool(v)
+		if err != nil {
+			addErr("deleted", err)
+		}
+		qp.filter.Deleted = deleted
+	}
+
+	// Archived todos are hidden unless asked for: "true" includes them and
+	// "only" returns nothing but them. Deleted todos are listed whether
+	// they were archived or not.
+	switch v := values.Get("archived"); v {
+	case "only":
+		archived := true
+		qp.filter.Archived = &archived
+	case "":
+		if !qp.filter.Deleted {
+			archived := false
+			qp.filter.Archived = &archived
+		}
+	default:
+		include, err := strconv.ParseBool(v)
+		if err != nil {
+			addErr("archived", fmt.Errorf("must be true, false or only"))
+		}
+		if !include {
+			qp.filter.Archived = &include
+		}
+	}
+
+	if v := values.Get("title"); v != "" {
+		qp.filter.Title = &v
+	}
+
+	if v := values.Get("expires_start"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			addErr("expires_start", err)
+		}
+		qp.filter.StartExpiresAt = &t
+	}
+
+	if v := values.Get("expires_end"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			addErr("expires_end", err)
+		}
+		qp.filter.EndExpiresAt = &t
+	}
+
+	if len(fields) > 0 {
+		return queryParams{}, errs.New(errs.InvalidArgument, fields)
+	}
+
+	return qp, nil
+}
+
+// -----------------------------------------------------------------------------
+
+// queryBuilder accumulates a SQL statement and its positional arguments so
+// user input is only ever passed as a bind parameter.
+type queryBuilder struct {
+	buf   strings.Builder
+	args  []any
+	where []string
+}
+
+// arg records the value and returns its placeholder.
+func (qb *queryBuilder) arg(v any) string {
+	qb.args = append(qb.args, v)
+	return "$" + strconv.Itoa(len(qb.args))
+}
+
+func (qb *queryBuilder) applyFilter(filter QueryFilter) {
+	if filter.Deleted {
+		qb.where = append(qb.where, "deleted_at IS NOT NULL")
+	} else {
+		qb.where = append(qb.where, "deleted_at IS NULL")
+	}
+
+	if filter.Status != nil {
+		qb.where = append(qb.where, "status = "+qb.arg(*filter.Status))
+	}
+
+	if filter.Archived != nil {
+		qb.where = append(qb.where, "archive = "+qb.arg(*filter.Archived))
+	}
+
+	if filter.Title != nil {
+		qb.where = append(qb.where, `title ILIKE `+qb.arg("%"+escapeLike(*filter.Title)+"%")+` ESCAPE '\'`)
+	}
+
+	if filter.StartExpiresAt != nil {
+		qb.where = append(qb.where, "expires_at >= "+qb.arg(*filter.StartExpiresAt))
+	}
+
+	if filter.EndExpiresAt != nil {
+		qb.where = append(qb.where, "expires_at <= "+qb.arg(*filter.EndExpiresAt))
+	}
+}
+
+func (qb *queryBuilder) applyCursor(after *cursor, direction string) {
+	if after == nil {
+		return
+	}
+
+	cmp := ">"
+	if direction == order.DESC {
+		cmp = "<"
+	}
+
+	qb.where = append(qb.where, fmt.Sprintf("(created_at, id) %s (%s, %s)", cmp, qb.arg(after.CreatedAt), qb.arg(after.ID)))
+}
+
+func (qb *queryBuilder) writeWhere() {
+	if len(qb.where) > 0 {
+		qb.buf.WriteString(" WHERE ")
+		qb.buf.WriteString(strings.Join(qb.where, " AND "))
+	}
+}
+
+// writeOrderBy writes the ordering. The field and direction come from the
+// order package, which only returns whitelisted values. The id is always
+// added as a tie breaker so paging is stable.
+func (qb *queryBuilder) writeOrderBy(orderBy order.By) {
+	qb.buf.WriteString(" ORDER BY ")
+	qb.buf.WriteString(orderBy.Field)
+	qb.buf.WriteString(" ")
+	qb.buf.WriteString(orderBy.Direction)
+
+	if orderBy.Field != "id" {
+		qb.buf.WriteString(", id ")
+		qb.buf.WriteString(orderBy.Direction)
+	}
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
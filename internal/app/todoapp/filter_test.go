@@ -0,0 +1,114 @@
+package todoapp
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/BuildFrom/Golang-Stdlib/internal/sdk/order"
+)
+
+func Test_ParseQueryParams(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		query string
+		err   bool
+	}{
+		{"Defaults", "", false},
+		{"AllFilters", "?page=2&rows=5&status=COMPLETE&archived=false&title=sql&expires_start=2024-11-01T00:00:00Z&expires_end=2024-12-01T00:00:00Z&orderBy=title,desc", false},
+		{"UnknownOrderField", "?orderBy=password", true},
+		{"UnknownStatus", "?status=DONE", true},
+		{"ArchivedOnly", "?archived=only&deleted=true", false},
+		{"ArchivedInvalid", "?archived=maybe", true},
+		{"RowsTooLarge", "?rows=1000", true},
+		{"CursorWithOtherOrder", "?orderBy=title&cursor=" + newCursor(Todo{ID: 1}).String(), true},
+		{"MalformedCursor", "?cursor=***", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseQueryParams(httptest.NewRequest("GET", "/"+tt.query, nil))
+			if tt.err && err == nil {
+				t.Errorf("Expected error, got nil")
+			}
+			if !tt.err && err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func Test_ParseQueryParamsArchivedDefault(t *testing.T) {
+	t.Parallel()
+
+	no, yes := false, true
+
+	tests := []struct {
+		query    string
+		expected *bool
+	}{
+		{"", &no},
+		{"?archived=true", nil},
+		{"?archived=only", &yes},
+		{"?deleted=true", nil},
+		{"?deleted=true&archived=false", &no},
+	}
+
+	for _, tt := range tests {
+		qp, err := parseQueryParams(httptest.NewRequest("GET", "/"+tt.query, nil))
+		if err != nil {
+			t.Fatalf("Expected no error for %q, got %v", tt.query, err)
+		}
+
+		got := qp.filter.Archived
+		if (got == nil) != (tt.expected == nil) || (got != nil && *got != *tt.expected) {
+			t.Errorf("Expected archived filter %v for %q, got %v", tt.expected, tt.query, got)
+		}
+	}
+}
+
+func Test_QueryBuilder(t *testing.T) {
+	t.Parallel()
+
+	title := "50%_off"
+	filter := QueryFilter{
+		Status: &Complete,
+		Title:  &title,
+	}
+	after := cursor{CreatedAt: time.Date(2024, 11, 16, 20, 25, 51, 0, time.UTC), ID: 7}
+
+	var qb queryBuilder
+	qb.buf.WriteString("SELECT id FROM todos")
+	qb.applyFilter(filter)
+	qb.applyCursor(&after, order.DESC)
+	qb.writeWhere()
+	qb.writeOrderBy(order.NewBy("created_at", order.DESC))
+
+	expected := `SELECT id FROM todos WHERE deleted_at IS NULL AND status = $1 AND title ILIKE $2 ESCAPE '\' AND (created_at, id) < ($3, $4) ORDER BY created_at DESC, id DESC`
+	if got := qb.buf.String(); got != expected {
+		t.Errorf("Expected query\n%s\ngot\n%s", expected, got)
+	}
+
+	if len(qb.args) != 4 {
+		t.Fatalf("Expected 4 args, got %d", len(qb.args))
+	}
+	if got := qb.args[1]; got != `%50\%\_off%` {
+		t.Errorf("Expected escaped title pattern, got %v", got)
+	}
+}
+
+func Test_Cursor(t *testing.T) {
+	t.Parallel()
+
+	c := cursor{CreatedAt: time.Date(2024, 11, 16, 20, 25, 51, 609243000, time.UTC), ID: 42}
+
+	got, err := parseCursor(c.String())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !got.CreatedAt.Equal(c.CreatedAt) || got.ID != c.ID {
+		t.Errorf("Expected %+v, got %+v", c, got)
+	}
+}
@@ -2,6 +2,7 @@ package todoapp
 
 import (
 	"encoding/json"
+	"strconv"
 	"time"
 
 	"github.com/BuildFrom/Golang-Stdlib/internal/sdk/errs"
@@ -9,11 +10,13 @@ import (
 
 type Todo struct {
 	ID        int        `json:"id,omitempty"`
-	Title     string     `json:"title" validate:"required, min=1,max=25" `
-	Status    string     `json:"status" validate:"required"`
+	Title     string     `json:"title"`
+	Status    Status     `json:"status"`
+	Archived  bool       `json:"archived"`
 	ExpiredAt *time.Time `json:"expired_at,omitempty"`
 	CreatedAt time.Time  `json:"created_at,omitempty"`
 	UpdatedAt time.Time  `json:"updated_at,omitempty"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
 }
 
 type Todos []Todo
@@ -30,8 +33,10 @@ func (ts Todos) Encode() ([]byte, string, error) {
 	return data, "application/json", err
 }
 
-type Encoder interface {
-	Encode() (data []byte, contentType string, err error)
+// ETag returns the entity tag for the current version of the todo. It is
+// derived from updated_at, which every write advances.
+func (t Todo) ETag() string {
+	return `"` + strconv.FormatInt(t.UpdatedAt.UnixMicro(), 36) + `"`
 }
 
 // -----------------------------------------------------------------------------
@@ -41,15 +46,85 @@ func (app *Todo) Decode(data []byte) error {
 	return json.Unmarshal(data, app)
 }
 
-type Decoder interface {
-	Decode(data []byte) error
+// -----------------------------------------------------------------------------
+
+// NewTodo is what we require from clients when creating or replacing a Todo.
+// The status is kept as a string so every invalid field can be reported at
+// once instead of failing on the first one during decoding.
+type NewTodo struct {
+	Title     string     `json:"title" validate:"required,min=1,max=25"`
+	Status    string     `json:"status" validate:"required"`
+	ExpiredAt *time.Time `json:"expired_at,omitempty"`
 }
 
-func (app Todo) Validate() error {
+// Decode implements the decoder interface.
+func (app *NewTodo) Decode(data []byte) error {
+	return json.Unmarshal(data, app)
+}
+
+// Validate checks the data in the model is considered clean.
+func (app NewTodo) Validate() error {
+	var fields errs.FieldErrors
+
 	if err := errs.Check(app); err != nil {
-		return errs.Newf(
-			errs.InvalidArgument, "invalidArgument: validate: %s", err)
+		fe := errs.GetFieldErrors(err)
+		if fe == nil {
+			return errs.Newf(errs.InvalidArgument, "validate: %s", err)
+		}
+		fields = fe
+	}
+
+	if app.Status != "" {
+		if _, err := Parse(app.Status); err != nil {
+			fields = append(fields, errs.FieldError{Field: "status", Err: err.Error()})
+		}
+	}
+
+	if len(fields) > 0 {
+		return errs.New(errs.InvalidArgument, fields)
 	}
 
 	return nil
 }
+
+// toTodo converts a validated NewTodo into a Todo.
+func toTodo(app NewTodo) Todo {
+	return Todo{
+		Title:     app.Title,
+		Status:    MustParse(app.Status),
+		ExpiredAt: app.ExpiredAt,
+	}
+}
+
+// -----------------------------------------------------------------------------
+
+// Columns that a partial update is allowed to change.
+const (
+	columnTitle     = "title"
+	columnStatus    = "status"
+	columnExpiresAt = "expires_at"
+)
+
+// changedColumns returns the columns whose values differ between the current
+// and the updated todo.
+func changedColumns(current Todo, updated Todo) []string {
+	var columns []string
+
+	if current.Title != updated.Title {
+		columns = append(columns, columnTitle)
+	}
+
+	if !current.Status.Equal(updated.Status) {
+		columns = append(columns, columnStatus)
+	}
+
+	switch {
+	case current.ExpiredAt == nil && updated.ExpiredAt == nil:
+	case current.ExpiredAt == nil || updated.ExpiredAt == nil:
+		columns = append(columns, columnExpiresAt)
+	case !current.ExpiredAt.Equal(*updated.ExpiredAt):
+		columns = append(columns, columnExpiresAt)
+	}
+
+	return columns
+}
@@ -0,0 +1,76 @@
+package todoapp
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/BuildFrom/Golang-Stdlib/internal/sdk/errs"
+)
+
+func Test_NewTodoValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		todo   NewTodo
+		fields []string
+	}{
+		{"Valid", NewTodo{Title: "Learn SQL", Status: "INCOMPLETE"}, nil},
+		{"MissingTitle", NewTodo{Status: "COMPLETE"}, []string{"title"}},
+		{"TitleTooLong", NewTodo{Title: "This title is far too long to be stored", Status: "COMPLETE"}, []string{"title"}},
+		{"UnknownStatus", NewTodo{Title: "Learn SQL", Status: "DONE"}, []string{"status"}},
+		{"AllInvalid", NewTodo{Status: "DONE"}, []string{"title", "status"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.todo.Validate()
+			if len(tt.fields) == 0 {
+				if err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+				return
+			}
+
+			var appErr *errs.Error
+			if !errors.As(err, &appErr) {
+				t.Fatalf("Expected errs.Error, got %v", err)
+			}
+			if appErr.Code != errs.InvalidArgument {
+				t.Errorf("Expected code %s, got %s", errs.InvalidArgument, appErr.Code)
+			}
+
+			fields := appErr.Fields.Fields()
+			if len(fields) != len(tt.fields) {
+				t.Fatalf("Expected %d field errors, got %v", len(tt.fields), appErr.Fields)
+			}
+			for _, f := range tt.fields {
+				if _, ok := fields[f]; !ok {
+					t.Errorf("Expected field error for %q, got %v", f, appErr.Fields)
+				}
+			}
+		})
+	}
+}
+
+func Test_StatusJSON(t *testing.T) {
+	t.Parallel()
+
+	data, err := json.Marshal(Todo{Title: "Learn SQL", Status: Complete})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var todo Todo
+	if err := json.Unmarshal(data, &todo); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !todo.Status.Equal(Complete) {
+		t.Errorf("Expected status %s, got %s", Complete, todo.Status)
+	}
+
+	if err := json.Unmarshal([]byte(`{"status":"DONE"}`), &todo); err == nil {
+		t.Errorf("Expected error for unknown status")
+	}
+}
@@ -0,0 +1,36 @@
+package todoapp
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/BuildFrom/Golang-Stdlib/internal/infrastructure/sqldb"
+)
+
+// RunPurge permanently removes todos that have been soft deleted for longer
+// than retention, checking every interval until the context is canceled.
+func RunPurge(ctx context.Context, log *slog.Logger, db sqldb.Service, interval time.Duration, retention time.Duration) {
+	repo := newStore(db)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			n, err := repo.purgeTodos(ctx, retention)
+			if err != nil {
+				log.ErrorContext(ctx, "todo purge", "error", err)
+				continue
+			}
+
+			if n > 0 {
+				log.InfoContext(ctx, "todo purge", "removed", n, "retention", retention)
+			}
+		}
+	}
+}
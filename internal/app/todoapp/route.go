@@ -1,36 +1,23 @@
 package todoapp
 
 import (
-	"net/http"
-
 	"github.com/BuildFrom/Golang-Stdlib/internal/infrastructure/sqldb"
-	mw "github.com/BuildFrom/Golang-Stdlib/internal/sdk/middleware"
+	"github.com/BuildFrom/Golang-Stdlib/internal/sdk/web"
 )
 
-func RegisterRoutes(mux *http.ServeMux, dbService sqldb.Service) http.Handler {
+// RegisterRoutes registers the todo routes on app, so they get the
+// middleware the app was built with.
+func RegisterRoutes(app *web.App, dbService sqldb.Service) {
 	repo := newStore(dbService)
 	api := newApp(repo)
 
-	mux.HandleFunc("POST /todo", api.createTodoHandler)
-	mux.HandleFunc("GET /{$}", api.getTodosHandler)
-	mux.HandleFunc("GET /todo/{id}", api.getTodoByIDHandler)
-	mux.HandleFunc("PUT /todo/{id}", api.updateTodoHandler)
-	mux.HandleFunc("DELETE /todo/{id}", api.deleteTodoHandler)
-
-	chains := []mw.Middleware{
-		mw.CORS,
-	}
-
-	return mw.WrapMiddleware(mux, chains...)
+	app.HandleFunc("POST /todo", api.createTodoHandler)
+	app.HandleFunc("GET /{$}", api.getTodosHandler)
+	app.HandleFunc("GET /todo/{id}", api.getTodoByIDHandler)
+	app.HandleFunc("PUT /todo/{id}", api.updateTodoHandler)
+	app.HandleFunc("PATCH /todo/{id}", api.patchTodoHandler)
+	app.HandleFunc("DELETE /todo/{id}", api.deleteTodoHandler)
+	app.HandleFunc("POST /todo/{id}/archive", api.archiveTodoHandler)
+	app.HandleFunc("POST /todo/{id}/unarchive", api.unarchiveTodoHandler)
+	app.HandleFunc("POST /todo/{id}/restore", api.restoreTodoHandler)
 }
-
-// dbService
-// repo := newStore()
-
-//
-
-// mux.HandleFunc("POST /todo", api.createTodoHandler)
-// mux.HandleFunc("GET /{$}", api.getTodosHandler)
-// mux.HandleFunc("PUT /todo/{id}", api.updateTodoHandler)
-// mux.HandleFunc("GET /todo/{id}", api.getTodoByIDHandler)
-// mux.HandleFunc("DELETE /todo/{id}", api.deleteTodoHandler)
@@ -0,0 +1,193 @@
+//go:build integration
+
+package todoapp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/BuildFrom/Golang-Stdlib/internal/infrastructure/sqldb"
+	"github.com/BuildFrom/Golang-Stdlib/internal/sdk/page"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// The store benchmarks run the CRUD paths against the test container three
+// ways: the store on the stdlib backend, the store on the pgxpool backend,
+// which still goes through database/sql, and the same statements sent
+// straight to the pgxpool.Pool to show what the adapter costs:
+//
+//	go test -tags integration -run '^$' -bench Store ./internal/app/todoapp
+func BenchmarkStore(b *testing.B) {
+	for _, driver := range []string{sqldb.DriverStdlib, sqldb.DriverPgxPool} {
+		b.Run(driver, func(b *testing.B) {
+			benchmarkStore(b, storeOps(newStore(openTestDB(b, driver))))
+		})
+	}
+
+	b.Run("pgx", func(b *testing.B) {
+		db := openTestDB(b, sqldb.DriverPgxPool)
+		benchmarkStore(b, poolOps(db.(sqldb.PoolProvider).Pool()))
+	})
+}
+
+// benchOps are the calls a benchmark arm makes. purge hard deletes the
+// given todos.
+type benchOps struct {
+	create func(ctx context.Context, todo Todo) (Todo, error)
+	get    func(ctx context.Context, id int) (Todo, error)
+	list   func(ctx context.Context, filter QueryFilter, pg page.Page) ([]Todo, error)
+	update func(ctx context.Context, todo Todo) error
+	delete func(ctx context.Context, id int) error
+	purge  func(ctx context.Context, ids []int) error
+}
+
+func storeOps(s *store) benchOps {
+	return benchOps{
+		create: s.createTodo,
+		get:    s.getTodoByID,
+		list: func(ctx context.Context, filter QueryFilter, pg page.Page) ([]Todo, error) {
+			return s.getTodos(ctx, filter, defaultOrderBy, pg, nil)
+		},
+		update: func(ctx context.Context, todo Todo) error {
+			_, err := s.updateTodo(ctx, todo, nil)
+			return err
+		},
+		delete: func(ctx context.Context, id int) error {
+			return s.deleteTodo(ctx, id, nil)
+		},
+		purge: func(ctx context.Context, ids []int) error {
+			_, err := s.db.ExecuteQueryContext(ctx, `DELETE FROM todos WHERE id = ANY($1)`, ids)
+			return err
+		},
+	}
+}
+
+// poolOps sends the statements the store uses straight to the pool.
+func poolOps(pool *pgxpool.Pool) benchOps {
+	return benchOps{
+		create: func(ctx context.Context, todo Todo) (Todo, error) {
+			query := `INSERT INTO todos (title, status, expires_at, created_at, updated_at)
+				VALUES ($1, $2, $3, now(), now())
+				RETURNING ` + todoColumns
+
+			return scanTodo(pool.QueryRow(ctx, query, todo.Title, todo.Status, todo.ExpiredAt))
+		},
+		get: func(ctx context.Context, id int) (Todo, error) {
+			query := `SELECT ` + todoColumns + ` FROM todos WHERE id = $1 AND deleted_at IS NULL`
+
+			return scanTodo(pool.QueryRow(ctx, query, id))
+		},
+		list: func(ctx context.Context, filter QueryFilter, pg page.Page) ([]Todo, error) {
+			var qb queryBuilder
+			qb.buf.WriteString(`SELECT ` + todoColumns + ` FROM todos`)
+			qb.applyFilter(filter)
+			qb.writeWhere()
+			qb.writeOrderBy(defaultOrderBy)
+			qb.buf.WriteString(" LIMIT " + qb.arg(pg.RowsPerPage()) + " OFFSET " + qb.arg(pg.Offset()))
+
+			rows, err := pool.Query(ctx, qb.buf.String(), qb.args...)
+			if err != nil {
+				return nil, err
+			}
+			defer rows.Close()
+
+			var todos []Todo
+			for rows.Next() {
+				todo, err := scanTodo(rows)
+				if err != nil {
+					return nil, err
+				}
+				todos = append(todos, todo)
+			}
+
+			return todos, rows.Err()
+		},
+		update: func(ctx context.Context, todo Todo) error {
+			query := `UPDATE todos SET title = $1, status = $2, expires_at = $3, updated_at = now()
+				WHERE id = $4 AND deleted_at IS NULL
+				RETURNING ` + todoColumns
+
+			_, err := scanTodo(pool.QueryRow(ctx, query, todo.Title, todo.Status, todo.ExpiredAt, todo.ID))
+			return err
+		},
+		delete: func(ctx context.Context, id int) error {
+			query := `UPDATE todos SET deleted_at = now(), updated_at = now()
+				WHERE id = $1 AND deleted_at IS NULL`
+
+			_, err := pool.Exec(ctx, query, id)
+			return err
+		},
+		purge: func(ctx context.Context, ids []int) error {
+			_, err := pool.Exec(ctx, `DELETE FROM todos WHERE id = ANY($1)`, ids)
+			return err
+		},
+	}
+}
+
+func benchmarkStore(b *testing.B, ops benchOps) {
+	ctx := context.Background()
+
+	// Every todo the benchmark creates is removed when it is done.
+	var created []int
+	b.Cleanup(func() {
+		if err := ops.purge(ctx, created); err != nil {
+			b.Errorf("could not remove the benchmark todos: %v", err)
+		}
+	})
+
+	create := func(b *testing.B) Todo {
+		todo, err := ops.create(ctx, Todo{Title: "Benchmark", Status: Incomplete})
+		if err != nil {
+			b.Fatal(err)
+		}
+		created = append(created, todo.ID)
+		return todo
+	}
+
+	seed := create(b)
+
+	b.Run("Create", func(b *testing.B) {
+		for range b.N {
+			create(b)
+		}
+	})
+
+	b.Run("Get", func(b *testing.B) {
+		for range b.N {
+			if _, err := ops.get(ctx, seed.ID); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("List", func(b *testing.B) {
+		archived := false
+		filter := QueryFilter{Archived: &archived}
+
+		for range b.N {
+			if _, err := ops.list(ctx, filter, page.MustParse("1", "10")); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("Update", func(b *testing.B) {
+		for range b.N {
+			if err := ops.update(ctx, seed); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("Delete", func(b *testing.B) {
+		for range b.N {
+			b.StopTimer()
+			todo := create(b)
+			b.StartTimer()
+
+			if err := ops.delete(ctx, todo.ID); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,354 @@
+//go:build integration
+
+package todoapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BuildFrom/Golang-Stdlib/internal/infrastructure/sqldb"
+	"github.com/BuildFrom/Golang-Stdlib/internal/infrastructure/sqldb/sqldbtest"
+	"github.com/BuildFrom/Golang-Stdlib/internal/sdk/errs"
+	"github.com/BuildFrom/Golang-Stdlib/internal/sdk/order"
+	"github.com/BuildFrom/Golang-Stdlib/internal/sdk/page"
+	"github.com/BuildFrom/Golang-Stdlib/internal/sdk/web"
+)
+
+// The store tests and benchmarks run against a Postgres container, so they
+// are behind the integration build tag and the handler tests in the rest of
+// the package run without Docker:
+//
+//	go test -tags integration ./internal/app/todoapp
+
+// testConfig points at the container started by TestMain. It stays empty
+// when sqldbtest.SkipEnv is set and no container could be started, in which
+// case the store tests are skipped.
+var testConfig sqldb.Config
+
+func TestMain(m *testing.M) {
+	sqldbtest.Main(m, func(c *sqldbtest.Container) {
+		testConfig = sqldb.Config{
+			Host:         c.Host,
+			Port:         c.Port,
+			Database:     c.Database,
+			User:         c.User,
+			Password:     c.Password,
+			MaxOpenConns: sqldb.DefaultMaxOpenConns,
+			MaxIdleConns: sqldb.DefaultMaxIdleConns,
+		}
+	})
+}
+
+// openTestDB opens the migrated test database with the given driver and
+// empties the todos table. The store tests share the table, so they do not
+// run in parallel.
+func openTestDB(tb testing.TB, driver string) sqldb.Service {
+	tb.Helper()
+
+	if testConfig.Host == "" {
+		tb.Skip("postgres container not available")
+	}
+
+	cfg := testConfig
+	cfg.Driver = driver
+
+	db, err := sqldb.Open(cfg)
+	if err != nil {
+		tb.Fatalf("Open() returned error: %v", err)
+	}
+	tb.Cleanup(func() { db.Close() })
+
+	ctx := context.Background()
+
+	if _, err := sqldb.MigrateUp(ctx, db); err != nil {
+		tb.Fatalf("MigrateUp() returned error: %v", err)
+	}
+
+	if _, err := db.ExecuteQueryContext(ctx, `TRUNCATE todos`); err != nil {
+		tb.Fatalf("could not empty todos: %v", err)
+	}
+
+	return db
+}
+
+// openTestStore returns a store on an empty todos table.
+func openTestStore(t *testing.T) *store {
+	t.Helper()
+
+	return newStore(openTestDB(t, sqldb.DriverStdlib))
+}
+
+// createTestTodos creates n todos, numbered in the order they were created.
+func createTestTodos(t *testing.T, s *store, n int) []Todo {
+	t.Helper()
+
+	todos := make([]Todo, n)
+	for i := range todos {
+		todo, err := s.createTodo(context.Background(), Todo{Title: fmt.Sprintf("Todo %d", i+1), Status: Incomplete})
+		if err != nil {
+			t.Fatalf("createTodo() returned error: %v", err)
+		}
+		todos[i] = todo
+	}
+
+	return todos
+}
+
+func Test_StoreRestore(t *testing.T) {
+	s := openTestStore(t)
+	ctx := context.Background()
+
+	todo := createTestTodos(t, s, 1)[0]
+
+	if _, err := s.restoreTodo(ctx, todo.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound restoring a live todo, got %v", err)
+	}
+
+	if err := s.deleteTodo(ctx, todo.ID, nil); err != nil {
+		t.Fatalf("deleteTodo() returned error: %v", err)
+	}
+
+	if _, err := s.getTodoByID(ctx, todo.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound for a deleted todo, got %v", err)
+	}
+
+	restored, err := s.restoreTodo(ctx, todo.ID)
+	if err != nil {
+		t.Fatalf("restoreTodo() returned error: %v", err)
+	}
+	if restored.DeletedAt != nil {
+		t.Errorf("Expected deleted_at to be cleared, got %v", restored.DeletedAt)
+	}
+
+	if _, err := s.restoreTodo(ctx, todo.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound restoring twice, got %v", err)
+	}
+}
+
+func Test_StorePurge(t *testing.T) {
+	s := openTestStore(t)
+	ctx := context.Background()
+
+	todos := createTestTodos(t, s, 3)
+	old, recent, live := todos[0], todos[1], todos[2]
+
+	for _, todo := range []Todo{old, recent} {
+		if err := s.deleteTodo(ctx, todo.ID, nil); err != nil {
+			t.Fatalf("deleteTodo() returned error: %v", err)
+		}
+	}
+
+	if _, err := s.db.ExecuteQueryContext(ctx, `UPDATE todos SET deleted_at = now() - interval '2 hours' WHERE id = $1`, old.ID); err != nil {
+		t.Fatalf("could not age todo: %v", err)
+	}
+
+	n, err := s.purgeTodos(ctx, time.Hour)
+	if err != nil {
+		t.Fatalf("purgeTodos() returned error: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("Expected 1 todo purged, got %d", n)
+	}
+
+	if _, err := s.restoreTodo(ctx, old.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected the old todo to be purged, got %v", err)
+	}
+	if _, err := s.restoreTodo(ctx, recent.ID); err != nil {
+		t.Errorf("Expected the recently deleted todo to be kept, got %v", err)
+	}
+	if _, err := s.getTodoByID(ctx, live.ID); err != nil {
+		t.Errorf("Expected the live todo to be kept, got %v", err)
+	}
+}
+
+func Test_StoreListDeleted(t *testing.T) {
+	s := openTestStore(t)
+	ctx := context.Background()
+
+	todos := createTestTodos(t, s, 3)
+	archived, deleted, live := todos[0], todos[1], todos[2]
+
+	if _, err := s.archiveTodo(ctx, archived.ID, true, nil); err != nil {
+		t.Fatalf("archiveTodo() returned error: %v", err)
+	}
+	for _, todo := range []Todo{archived, deleted} {
+		if err := s.deleteTodo(ctx, todo.ID, nil); err != nil {
+			t.Fatalf("deleteTodo() returned error: %v", err)
+		}
+	}
+
+	tests := []struct {
+		query    string
+		expected []int
+	}{
+		{"", []int{live.ID}},
+		{"?deleted=true", []int{archived.ID, deleted.ID}},
+		{"?deleted=true&archived=only", []int{archived.ID}},
+	}
+
+	for _, tt := range tests {
+		qp, err := parseQueryParams(httptest.NewRequest("GET", "/"+tt.query, nil))
+		if err != nil {
+			t.Fatalf("parseQueryParams(%q) returned error: %v", tt.query, err)
+		}
+
+		got, err := s.getTodos(ctx, qp.filter, qp.orderBy, qp.page, nil)
+		if err != nil {
+			t.Fatalf("getTodos(%q) returned error: %v", tt.query, err)
+		}
+
+		if ids := todoIDs(got); !slices.Equal(ids, tt.expected) {
+			t.Errorf("Expected %v for %q, got %v", tt.expected, tt.query, ids)
+		}
+
+		count, err := s.countTodos(ctx, qp.filter)
+		if err != nil {
+			t.Fatalf("countTodos(%q) returned error: %v", tt.query, err)
+		}
+		if count != len(tt.expected) {
+			t.Errorf("Expected a count of %d for %q, got %d", len(tt.expected), tt.query, count)
+		}
+	}
+}
+
+func Test_StoreCursorPaging(t *testing.T) {
+	s := openTestStore(t)
+	ctx := context.Background()
+
+	todos := createTestTodos(t, s, 7)
+
+	expected := todoIDs(todos)
+
+	for _, direction := range []string{order.ASC, order.DESC} {
+		t.Run(direction, func(t *testing.T) {
+			orderBy := order.NewBy(keysetField, direction)
+
+			var got []int
+			var after *cursor
+
+			for range len(todos) {
+				pg, err := s.getTodos(ctx, QueryFilter{}, orderBy, page.MustParse("1", "3"), after)
+				if err != nil {
+					t.Fatalf("getTodos() returned error: %v", err)
+				}
+				if len(pg) == 0 {
+					break
+				}
+
+				got = append(got, todoIDs(pg)...)
+
+				c := newCursor(pg[len(pg)-1])
+				after = &c
+			}
+
+			want := slices.Clone(expected)
+			if direction == order.DESC {
+				slices.Reverse(want)
+			}
+
+			if !slices.Equal(got, want) {
+				t.Errorf("Expected every todo once in order %v, got %v", want, got)
+			}
+		})
+	}
+}
+
+func Test_StoreVersionMatch(t *testing.T) {
+	s := openTestStore(t)
+	ctx := context.Background()
+
+	todo := createTestTodos(t, s, 1)[0]
+	stale := todo.UpdatedAt
+
+	current, err := s.updateTodo(ctx, Todo{ID: todo.ID, Title: "Learn SQL", Status: Complete}, &stale)
+	if err != nil {
+		t.Fatalf("Expected a write matching the current version, got %v", err)
+	}
+	if !current.UpdatedAt.After(stale) {
+		t.Fatalf("Expected updated_at to advance past %v, got %v", stale, current.UpdatedAt)
+	}
+
+	writes := map[string]func(match *time.Time) error{
+		"Update": func(match *time.Time) error {
+			_, err := s.updateTodo(ctx, Todo{ID: todo.ID, Title: "Learn Go", Status: Complete}, match)
+			return err
+		},
+		"Patch": func(match *time.Time) error {
+			_, err := s.patchTodo(ctx, Todo{ID: todo.ID, Title: "Learn Go"}, []string{columnTitle}, match)
+			return err
+		},
+		"Archive": func(match *time.Time) error {
+			_, err := s.archiveTodo(ctx, todo.ID, true, match)
+			return err
+		},
+		"Delete": func(match *time.Time) error {
+			return s.deleteTodo(ctx, todo.ID, match)
+		},
+	}
+
+	for name, write := range writes {
+		err := write(&stale)
+		if !errors.Is(err, ErrVersionMismatch) {
+			t.Fatalf("%s: expected ErrVersionMismatch, got %v", name, err)
+		}
+
+		var appErr *errs.Error
+		if !errors.As(toAppError("error writing todo", err), &appErr) || appErr.HTTPStatus() != http.StatusPreconditionFailed {
+			t.Errorf("%s: expected status %d, got %v", name, http.StatusPreconditionFailed, appErr)
+		}
+	}
+
+	got, err := s.getTodoByID(ctx, todo.ID)
+	if err != nil {
+		t.Fatalf("getTodoByID() returned error: %v", err)
+	}
+	if !got.UpdatedAt.Equal(current.UpdatedAt) || got.Title != current.Title || got.Archived {
+		t.Errorf("Expected stale writes to leave %+v unchanged, got %+v", current, got)
+	}
+}
+
+func Test_StoreIfMatch(t *testing.T) {
+	s := openTestStore(t)
+	api := newApp(s)
+
+	todo := createTestTodos(t, s, 1)[0]
+
+	put := func(etag string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPut, "/todo/1", strings.NewReader(`{"title": "Learn SQL", "status": "COMPLETE"}`))
+		r.SetPathValue("id", strconv.Itoa(todo.ID))
+		r.Header.Set("If-Match", etag)
+		w := httptest.NewRecorder()
+
+		web.HandlerFunc(api.updateTodoHandler).ServeHTTP(w, r)
+
+		return w
+	}
+
+	w := put(todo.ETag())
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+	}
+	if w.Header().Get("ETag") == todo.ETag() {
+		t.Errorf("Expected a new ETag after the write, got %s", todo.ETag())
+	}
+
+	if w := put(todo.ETag()); w.Code != http.StatusPreconditionFailed {
+		t.Errorf("Expected status %d for a stale ETag, got %d: %s", http.StatusPreconditionFailed, w.Code, w.Body)
+	}
+}
+
+func todoIDs(todos []Todo) []int {
+	ids := make([]int, len(todos))
+	for i, todo := range todos {
+		ids[i] = todo.ID
+	}
+	return ids
+}
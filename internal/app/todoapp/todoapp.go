@@ -1,24 +1,49 @@
 package todoapp
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"mime"
 	"net/http"
-	"strconv"
+	"time"
 
 	"github.com/BuildFrom/Golang-Stdlib/internal/infrastructure/sqldb"
+	"github.com/BuildFrom/Golang-Stdlib/internal/sdk/errs"
+	"github.com/BuildFrom/Golang-Stdlib/internal/sdk/jsonpatch"
+	"github.com/BuildFrom/Golang-Stdlib/internal/sdk/order"
+	"github.com/BuildFrom/Golang-Stdlib/internal/sdk/page"
+	"github.com/BuildFrom/Golang-Stdlib/internal/sdk/web"
 )
 
 // -----------------------------------------------------------------------------
 
+// ErrNotFound is returned by a TodoRepository when the requested todo does
+// not exist. Callers should check for it with errors.Is.
+var ErrNotFound = errors.New("todo not found")
+
+// ErrVersionMismatch is returned by a TodoRepository when a conditional write
+// finds the todo was modified since the version the caller expected.
+var ErrVersionMismatch = errors.New("todo version mismatch")
+
+// -----------------------------------------------------------------------------
+
 type TodoRepository interface {
-	getTodos() ([]Todo, error)
-	getTodoByID(id int) (Todo, error)
-	createTodo(todo Todo) error
-	updateTodo(todo Todo) error
-	deleteTodo(id int) error
+	getTodos(ctx context.Context, filter QueryFilter, orderBy order.By, pg page.Page, after *cursor) ([]Todo, error)
+	countTodos(ctx context.Context, filter QueryFilter) (int, error)
+	getTodoByID(ctx context.Context, id int) (Todo, error)
+	createTodo(ctx context.Context, todo Todo) (Todo, error)
+	updateTodo(ctx context.Context, todo Todo, match *time.Time) (Todo, error)
+	patchTodo(ctx context.Context, todo Todo, columns []string, match *time.Time) (Todo, error)
+	deleteTodo(ctx context.Context, id int, match *time.Time) error
+	archiveTodo(ctx context.Context, id int, archived bool, match *time.Time) (Todo, error)
+	restoreTodo(ctx context.Context, id int) (Todo, error)
+
+	// inTx runs fn so that every repository call made with the context it
+	// receives is part of one transaction.
+	inTx(ctx context.Context, fn func(ctx context.Context) error) error
 }
 
 // -----------------------------------------------------------------------------
@@ -35,22 +60,75 @@ func newApp(repo TodoRepository) *app {
 
 // -----------------------------------------------------------------------------
 
+// queryTimeout bounds how long a single store call may take, independently
+// of the deadline of the request that triggered it.
+const queryTimeout = 5 * time.Second
+
 type store struct {
-	db sqldb.Service
+	db      sqldb.Service
+	timeout time.Duration
 }
 
 func newStore(db sqldb.Service) *store {
 	return &store{
-		db: db,
+		db:      db,
+		timeout: queryTimeout,
 	}
 }
 
 // -----------------------------------------------------------------------------
 
-func (s *store) getTodos() ([]Todo, error) {
-	query := `SELECT id, title, status, expires_at AS expired_at, created_at FROM todos`
+// todoColumns is the column list every read and RETURNING clause uses, in
+// the order scanTodo expects.
+const todoColumns = `id, title, status, archive, expires_at, created_at, updated_at, deleted_at`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// versionMatch is appended to the WHERE clause of every write so it only
+// applies when the caller's expected updated_at, if any, is still current.
+const versionMatch = `(%[1]s::timestamp IS NULL OR updated_at = %[1]s)`
+
+// writeError converts the error from a write that returned no row, taking
+// into account whether the write was conditional.
+func writeError(id int, match *time.Time, err error) error {
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	if match != nil {
+		return fmt.Errorf("todo with id %d: %w", id, ErrVersionMismatch)
+	}
+
+	return fmt.Errorf("todo with id %d: %w", id, ErrNotFound)
+}
+
+func scanTodo(row scanner) (Todo, error) {
+	var todo Todo
+	err := row.Scan(&todo.ID, &todo.Title, &todo.Status, &todo.Archived, &todo.ExpiredAt, &todo.CreatedAt, &todo.UpdatedAt, &todo.DeletedAt)
+	return todo, err
+}
+
+// -----------------------------------------------------------------------------
+
+func (s *store) getTodos(ctx context.Context, filter QueryFilter, orderBy order.By, pg page.Page, after *cursor) ([]Todo, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	var qb queryBuilder
+	qb.buf.WriteString(`SELECT ` + todoColumns + ` FROM todos`)
+	qb.applyFilter(filter)
+	qb.applyCursor(after, orderBy.Direction)
+	qb.writeWhere()
+	qb.writeOrderBy(orderBy)
+
+	qb.buf.WriteString(" LIMIT " + qb.arg(pg.RowsPerPage()))
+	if after == nil {
+		qb.buf.WriteString(" OFFSET " + qb.arg(pg.Offset()))
+	}
 
-	rows, err := s.db.Query(query)
+	rows, err := s.db.QueryContext(ctx, qb.buf.String(), qb.args...)
 	if err != nil {
 		return nil, err
 	}
@@ -58,169 +136,507 @@ func (s *store) getTodos() ([]Todo, error) {
 
 	var todos []Todo
 	for rows.Next() {
-		var todo Todo
-		if err := rows.Scan(&todo.ID, &todo.Title, &todo.Status, &todo.ExpiredAt, &todo.CreatedAt); err != nil {
+		todo, err := scanTodo(rows)
+		if err != nil {
 			return nil, err
 		}
 		todos = append(todos, todo)
 	}
 
-	return todos, nil
+	return todos, rows.Err()
 }
 
-func (s *store) getTodoByID(id int) (Todo, error) {
-	query := `SELECT id, title, status, expires_at AS expired_at, created_at FROM todos WHERE id = $1`
+func (s *store) countTodos(ctx context.Context, filter QueryFilter) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
 
-	var todo Todo
-	err := s.db.QueryRow(query, id).Scan(&todo.ID, &todo.Title, &todo.Status, &todo.ExpiredAt, &todo.CreatedAt)
+	var qb queryBuilder
+	qb.buf.WriteString(`SELECT COUNT(*) FROM todos`)
+	qb.applyFilter(filter)
+	qb.writeWhere()
+
+	var count int
+	if err := s.db.QueryRowContext(ctx, qb.buf.String(), qb.args...).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (s *store) getTodoByID(ctx context.Context, id int) (Todo, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `SELECT ` + todoColumns + ` FROM todos WHERE id = $1 AND deleted_at IS NULL`
+
+	todo, err := scanTodo(s.db.QueryRowContext(ctx, query, id))
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return Todo{}, fmt.Errorf("todo with id %d not found", id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return Todo{}, fmt.Errorf("todo with id %d: %w", id, ErrNotFound)
 		}
-		return Todo{}, err
+		return Todo{}, fmt.Errorf("failed to get todo with id %d: %w", id, err)
 	}
 
 	return todo, nil
 }
 
-func (s *store) createTodo(todo Todo) error {
-	query := `INSERT INTO todos (title, status, expires_at) VALUES ($1, $2, $3)`
+func (s *store) createTodo(ctx context.Context, todo Todo) (Todo, error) {
+	ctx, cancel := context.WithTimeout(sqldb.WithPrimary(ctx), s.timeout)
+	defer cancel()
+
+	query := `INSERT INTO todos (title, status, expires_at, created_at, updated_at)
+		VALUES ($1, $2, $3, now(), now())
+		RETURNING ` + todoColumns
 
-	_, err := s.db.ExecuteQuery(query, todo.Title, todo.Status, todo.ExpiredAt)
+	created, err := scanTodo(s.db.QueryRowContext(ctx, query, todo.Title, todo.Status, todo.ExpiredAt))
 	if err != nil {
-		return fmt.Errorf("failed to create todo: %v", err)
+		return Todo{}, fmt.Errorf("failed to create todo: %w", err)
 	}
 
-	return nil
+	return created, nil
+}
+
+// updateTodo replaces the todo. When match is not nil the write only happens
+// if the todo's updated_at still equals it.
+func (s *store) updateTodo(ctx context.Context, todo Todo, match *time.Time) (Todo, error) {
+	ctx, cancel := context.WithTimeout(sqldb.WithPrimary(ctx), s.timeout)
+	defer cancel()
+
+	query := `UPDATE todos SET title = $1, status = $2, expires_at = $3, updated_at = now()
+		WHERE id = $4 AND deleted_at IS NULL AND ` + fmt.Sprintf(versionMatch, "$5") + `
+		RETURNING ` + todoColumns
+
+	updated, err := scanTodo(s.db.QueryRowContext(ctx, query, todo.Title, todo.Status, todo.ExpiredAt, todo.ID, match))
+	if err != nil {
+		return Todo{}, fmt.Errorf("failed to update todo with id %d: %w", todo.ID, writeError(todo.ID, match, err))
+	}
+
+	return updated, nil
 }
 
-func (s *store) updateTodo(todo Todo) error {
-	query := `UPDATE todos SET title = $1, status = $2, expires_at = $3 WHERE id = $4`
+// patchTodo writes only the provided columns of the todo, along with a new
+// updated_at. match behaves as it does for updateTodo.
+func (s *store) patchTodo(ctx context.Context, todo Todo, columns []string, match *time.Time) (Todo, error) {
+	ctx, cancel := context.WithTimeout(sqldb.WithPrimary(ctx), s.timeout)
+	defer cancel()
+
+	var qb queryBuilder
+	qb.buf.WriteString("UPDATE todos SET ")
+
+	for _, column := range columns {
+		var v any
+		switch column {
+		case columnTitle:
+			v = todo.Title
+		case columnStatus:
+			v = todo.Status
+		case columnExpiresAt:
+			v = todo.ExpiredAt
+		default:
+			return Todo{}, fmt.Errorf("failed to patch todo with id %d: unknown column %q", todo.ID, column)
+		}
+
+		qb.buf.WriteString(column + " = " + qb.arg(v) + ", ")
+	}
 
-	_, err := s.db.ExecuteQuery(query, todo.Title, todo.Status, todo.ExpiredAt, todo.ID)
+	qb.buf.WriteString("updated_at = now() WHERE id = " + qb.arg(todo.ID) + " AND deleted_at IS NULL")
+	qb.buf.WriteString(" AND " + fmt.Sprintf(versionMatch, qb.arg(match)))
+	qb.buf.WriteString(" RETURNING " + todoColumns)
+
+	updated, err := scanTodo(s.db.QueryRowContext(ctx, qb.buf.String(), qb.args...))
 	if err != nil {
-		return fmt.Errorf("failed to update todo with id %d: %v", todo.ID, err)
+		return Todo{}, fmt.Errorf("failed to patch todo with id %d: %w", todo.ID, writeError(todo.ID, match, err))
 	}
 
-	return nil
+	return updated, nil
 }
 
-func (s *store) deleteTodo(id int) error {
-	query := `DELETE FROM todos WHERE id = $1`
+// deleteTodo soft deletes the todo by stamping deleted_at. The row is kept
+// so it can be restored until the purge job removes it. match behaves as it
+// does for updateTodo.
+func (s *store) deleteTodo(ctx context.Context, id int, match *time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
 
-	_, err := s.db.ExecuteQuery(query, id)
+	query := `UPDATE todos SET deleted_at = now(), updated_at = now()
+		WHERE id = $1 AND deleted_at IS NULL AND ` + fmt.Sprintf(versionMatch, "$2")
+
+	result, err := s.db.ExecuteQueryContext(ctx, query, id, match)
+	if err != nil {
+		return fmt.Errorf("failed to delete todo with id %d: %w", id, err)
+	}
+
+	n, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("failed to delete todo with id %d: %v", id, err)
+		return fmt.Errorf("failed to delete todo with id %d: %w", id, err)
+	}
+
+	if n == 0 {
+		return writeError(id, match, sql.ErrNoRows)
 	}
 
 	return nil
 }
 
-// -----------------------------------------------------------------------------
+// archiveTodo sets the archive flag of the todo. match behaves as it does for
+// updateTodo.
+func (s *store) archiveTodo(ctx context.Context, id int, archived bool, match *time.Time) (Todo, error) {
+	ctx, cancel := context.WithTimeout(sqldb.WithPrimary(ctx), s.timeout)
+	defer cancel()
+
+	query := `UPDATE todos SET archive = $2, updated_at = now()
+		WHERE id = $1 AND deleted_at IS NULL AND ` + fmt.Sprintf(versionMatch, "$3") + `
+		RETURNING ` + todoColumns
 
-func (a *app) getTodosHandler(w http.ResponseWriter, r *http.Request) {
-	todos, err := a.repo.getTodos()
+	todo, err := scanTodo(s.db.QueryRowContext(ctx, query, id, archived, match))
 	if err != nil {
-		http.Error(w, "Error fetching todos: "+err.Error(), http.StatusInternalServerError)
-		return
+		return Todo{}, fmt.Errorf("failed to archive todo with id %d: %w", id, writeError(id, match, err))
 	}
 
-	encodedData, err := json.Marshal(todos)
+	return todo, nil
+}
+
+// restoreTodo brings back a soft deleted todo.
+func (s *store) restoreTodo(ctx context.Context, id int) (Todo, error) {
+	ctx, cancel := context.WithTimeout(sqldb.WithPrimary(ctx), s.timeout)
+	defer cancel()
+
+	query := `UPDATE todos SET deleted_at = NULL, updated_at = now()
+		WHERE id = $1 AND deleted_at IS NOT NULL
+		RETURNING ` + todoColumns
+
+	todo, err := scanTodo(s.db.QueryRowContext(ctx, query, id))
 	if err != nil {
-		log.Printf("Error encoding response: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
+		return Todo{}, fmt.Errorf("failed to restore todo with id %d: %w", id, writeError(id, nil, err))
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	_, err = w.Write(encodedData)
+	return todo, nil
+}
+
+// inTx runs fn in a repeatable read transaction. Store methods called with
+// the context fn receives join it; a serialization failure reruns fn.
+func (s *store) inTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return s.db.InTx(ctx, sqldb.TxOptions{Isolation: sql.LevelRepeatableRead}, fn)
+}
+
+// purgeTodos permanently removes todos that were soft deleted longer ago
+// than the retention period and returns how many were removed.
+func (s *store) purgeTodos(ctx context.Context, retention time.Duration) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `DELETE FROM todos WHERE deleted_at IS NOT NULL AND deleted_at < now() - make_interval(secs => $1)`
+
+	result, err := s.db.ExecuteQueryContext(ctx, query, retention.Seconds())
 	if err != nil {
-		log.Printf("Error writing response: %v", err)
+		return 0, fmt.Errorf("failed to purge todos: %w", err)
 	}
+
+	return result.RowsAffected()
 }
 
-func (a *app) getTodoByIDHandler(w http.ResponseWriter, r *http.Request) {
-	id, err := strconv.Atoi(r.PathValue("id"))
+// -----------------------------------------------------------------------------
+
+func (a *app) getTodosHandler(w http.ResponseWriter, r *http.Request) (web.Encoder, error) {
+	ctx := r.Context()
+
+	qp, err := parseQueryParams(r)
 	if err != nil {
-		http.Error(w, "Invalid ID format", http.StatusBadRequest)
-		return
+		return nil, err
 	}
 
-	todo, err := a.repo.getTodoByID(id)
+	todos, err := a.repo.getTodos(ctx, qp.filter, qp.orderBy, qp.page, qp.after)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
-		return
+		return nil, toAppError("error fetching todos", err)
 	}
 
-	encodedData, err := json.Marshal(todo)
+	total, err := a.repo.countTodos(ctx, qp.filter)
 	if err != nil {
-		log.Printf("Error encoding response: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
+		return nil, toAppError("error counting todos", err)
+	}
+
+	// A next cursor is only meaningful when ordering by the keyset column
+	// and the page came back full.
+	var next string
+	if qp.orderBy.Field == keysetField && len(todos) == qp.page.RowsPerPage() {
+		next = newCursor(todos[len(todos)-1]).String()
+	}
+
+	return page.NewDocument(todos, total, qp.page, next), nil
+}
+
+func (a *app) getTodoByIDHandler(w http.ResponseWriter, r *http.Request) (web.Encoder, error) {
+	ctx := r.Context()
+
+	id, err := web.ParamInt(r, "id")
+	if err != nil {
+		return nil, err
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	_, err = w.Write(encodedData)
+	todo, err := a.repo.getTodoByID(ctx, id)
 	if err != nil {
-		log.Printf("Error writing response: %v", err)
+		return nil, toAppError("error fetching todo", err)
 	}
+
+	etag := todo.ETag()
+	w.Header().Set("ETag", etag)
+
+	if web.IfNoneMatch(r, etag) {
+		return web.NewResponse(http.StatusNotModified, nil), nil
+	}
+
+	return todo, nil
 }
 
-func (a *app) updateTodoHandler(w http.ResponseWriter, r *http.Request) {
-	id, err := strconv.Atoi(r.PathValue("id"))
+func (a *app) updateTodoHandler(w http.ResponseWriter, r *http.Request) (web.Encoder, error) {
+	ctx := r.Context()
+
+	id, err := web.ParamInt(r, "id")
 	if err != nil {
-		http.Error(w, "Invalid ID format", http.StatusBadRequest)
-		return
+		return nil, err
 	}
 
-	var updatedTodo Todo
-	err = json.NewDecoder(r.Body).Decode(&updatedTodo)
+	var app NewTodo
+	if err := web.Decode(r, &app); err != nil {
+		return nil, err
+	}
+
+	match, err := a.checkIfMatch(r, id)
 	if err != nil {
-		http.Error(w, "Invalid input", http.StatusBadRequest)
-		return
+		return nil, err
 	}
 
+	updatedTodo := toTodo(app)
 	updatedTodo.ID = id
-	err = a.repo.updateTodo(updatedTodo)
+	todo, err := a.repo.updateTodo(ctx, updatedTodo, match)
 	if err != nil {
-		http.Error(w, "Error updating todo: "+err.Error(), http.StatusInternalServerError)
-		return
+		return nil, toAppError("error updating todo", err)
 	}
 
-	w.WriteHeader(http.StatusNoContent)
+	w.Header().Set("ETag", todo.ETag())
+
+	return todo, nil
+}
+
+// patchTodoHandler applies a JSON Merge Patch or JSON Patch document to the
+// current todo and persists the columns that changed.
+func (a *app) patchTodoHandler(w http.ResponseWriter, r *http.Request) (web.Encoder, error) {
+	ctx := r.Context()
+
+	w.Header().Set("Accept-Patch", jsonpatch.MergePatchType+", "+jsonpatch.JSONPatchType)
+
+	id, err := web.ParamInt(r, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	var applyPatch func(doc []byte, patch []byte) ([]byte, error)
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case jsonpatch.MergePatchType:
+		applyPatch = jsonpatch.MergePatch
+	case jsonpatch.JSONPatchType:
+		applyPatch = jsonpatch.Apply
+	default:
+		return nil, errs.Newf(errs.UnsupportedMediaType, "unsupported patch media type %q", mediaType)
+	}
+
+	patch, err := web.ReadBody(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Reading the current version, applying the patch and writing it back
+	// happen in one transaction. The write is also conditional on the
+	// version the patch was applied to, so a concurrent change is never
+	// silently overwritten.
+	var updated Todo
+
+	err = a.repo.inTx(ctx, func(ctx context.Context) error {
+		current, err := a.repo.getTodoByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if !web.IfMatch(r, current.ETag()) {
+			return errs.Newf(errs.PreconditionFailed, "todo with id %d has been modified", id)
+		}
+
+		doc, err := json.Marshal(current)
+		if err != nil {
+			return errs.Newf(errs.Internal, "error encoding todo: %s", err)
+		}
+
+		patched, err := applyPatch(doc, patch)
+		if err != nil {
+			if errors.Is(err, jsonpatch.ErrTestFailed) {
+				return errs.Newf(errs.Aborted, "patch: %s", err)
+			}
+			return errs.Newf(errs.InvalidArgument, "patch: %s", err)
+		}
+
+		var app NewTodo
+		if err := app.Decode(patched); err != nil {
+			return errs.Newf(errs.InvalidArgument, "patch: %s", err)
+		}
+
+		if err := app.Validate(); err != nil {
+			return err
+		}
+
+		todo := toTodo(app)
+		todo.ID = id
+
+		columns := changedColumns(current, todo)
+		if len(columns) == 0 {
+			updated = current
+			return nil
+		}
+
+		updated, err = a.repo.patchTodo(ctx, todo, columns, &current.UpdatedAt)
+		return err
+	})
+	if err != nil {
+		return nil, toAppError("error patching todo", err)
+	}
+
+	w.Header().Set("ETag", updated.ETag())
+
+	return updated, nil
+}
+
+func (a *app) deleteTodoHandler(w http.ResponseWriter, r *http.Request) (web.Encoder, error) {
+	ctx := r.Context()
+
+	id, err := web.ParamInt(r, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	match, err := a.checkIfMatch(r, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.repo.deleteTodo(ctx, id, match); err != nil {
+		return nil, toAppError("error deleting todo", err)
+	}
+
+	return nil, nil
+}
+
+func (a *app) archiveTodoHandler(w http.ResponseWriter, r *http.Request) (web.Encoder, error) {
+	return a.setArchived(w, r, true)
+}
+
+func (a *app) unarchiveTodoHandler(w http.ResponseWriter, r *http.Request) (web.Encoder, error) {
+	return a.setArchived(w, r, false)
 }
 
-func (a *app) deleteTodoHandler(w http.ResponseWriter, r *http.Request) {
-	id, err := strconv.Atoi(r.PathValue("id"))
+func (a *app) setArchived(w http.ResponseWriter, r *http.Request, archived bool) (web.Encoder, error) {
+	ctx := r.Context()
+
+	id, err := web.ParamInt(r, "id")
 	if err != nil {
-		http.Error(w, "Invalid ID format", http.StatusBadRequest)
-		return
+		return nil, err
 	}
 
-	err = a.repo.deleteTodo(id)
+	match, err := a.checkIfMatch(r, id)
 	if err != nil {
-		http.Error(w, "Error deleting todo: "+err.Error(), http.StatusInternalServerError)
-		return
+		return nil, err
 	}
 
-	w.WriteHeader(http.StatusNoContent)
+	todo, err := a.repo.archiveTodo(ctx, id, archived, match)
+	if err != nil {
+		return nil, toAppError("error archiving todo", err)
+	}
+
+	w.Header().Set("ETag", todo.ETag())
+
+	return todo, nil
 }
 
-func (a *app) createTodoHandler(w http.ResponseWriter, r *http.Request) {
-	var newTodo Todo
-	err := json.NewDecoder(r.Body).Decode(&newTodo)
+func (a *app) restoreTodoHandler(w http.ResponseWriter, r *http.Request) (web.Encoder, error) {
+	ctx := r.Context()
+
+	id, err := web.ParamInt(r, "id")
 	if err != nil {
-		http.Error(w, "Invalid input", http.StatusBadRequest)
-		return
+		return nil, err
 	}
 
-	err = a.repo.createTodo(newTodo)
+	todo, err := a.repo.restoreTodo(ctx, id)
 	if err != nil {
-		http.Error(w, "Error creating todo: "+err.Error(), http.StatusInternalServerError)
-		return
+		return nil, toAppError("error restoring todo", err)
+	}
+
+	w.Header().Set("ETag", todo.ETag())
+
+	return todo, nil
+}
+
+func (a *app) createTodoHandler(w http.ResponseWriter, r *http.Request) (web.Encoder, error) {
+	ctx := r.Context()
+
+	var app NewTodo
+	if err := web.Decode(r, &app); err != nil {
+		return nil, err
+	}
+
+	todo, err := a.repo.createTodo(ctx, toTodo(app))
+	if err != nil {
+		return nil, toAppError("error creating todo", err)
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/todo/%d", todo.ID))
+	w.Header().Set("ETag", todo.ETag())
+
+	return web.NewResponse(http.StatusCreated, todo), nil
+}
+
+// checkIfMatch evaluates the If-Match header of the request against the
+// current todo. It returns the version a write must be conditional on, or
+// nil when the request carries no If-Match header.
+func (a *app) checkIfMatch(r *http.Request, id int) (*time.Time, error) {
+	ctx := r.Context()
+
+	if r.Header.Get("If-Match") == "" {
+		return nil, nil
+	}
+
+	// Compare against the primary so a lagging replica cannot fail the
+	// precondition.
+	current, err := a.repo.getTodoByID(sqldb.WithPrimary(ctx), id)
+	if err != nil {
+		return nil, toAppError("error fetching todo", err)
+	}
+
+	if !web.IfMatch(r, current.ETag()) {
+		return nil, errs.Newf(errs.PreconditionFailed, "todo with id %d has been modified", id)
+	}
+
+	return &current.UpdatedAt, nil
+}
+
+// toAppError maps a store error onto the errs code the client should see.
+// Errors that already carry a code are returned as they are, and database
+// errors are translated by sqldb.Translate. Anything else is only logged, as
+// driver errors can name hosts, constraints and queries.
+func toAppError(msg string, err error) error {
+	var appErr *errs.Error
+
+	switch {
+	case errors.As(sqldb.Translate(err), &appErr):
+		return appErr
+	case errors.Is(err, ErrNotFound):
+		return errs.New(errs.NotFound, err)
+	case errors.Is(err, ErrVersionMismatch):
+		return errs.New(errs.PreconditionFailed, err)
+	case errors.Is(err, context.DeadlineExceeded):
+		return errs.New(errs.DeadlineExceeded, err)
+	case errors.Is(err, context.Canceled):
+		return errs.New(errs.Canceled, err)
 	}
 
-	w.WriteHeader(http.StatusCreated)
+	return errs.Newf(errs.InternalOnlyLog, "%s: %s", msg, err)
 }
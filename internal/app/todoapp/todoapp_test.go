@@ -0,0 +1,317 @@
+package todoapp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BuildFrom/Golang-Stdlib/internal/sdk/web"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func Test_Handlers(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 11, 16, 20, 25, 51, 0, time.UTC)
+
+	mockRepo := &MockTodoRepository{
+		CreateTodoFunc: func(todo Todo) (Todo, error) {
+			todo.ID = 7
+			todo.CreatedAt = now
+			todo.UpdatedAt = now
+			return todo, nil
+		},
+		UpdateTodoFunc: func(todo Todo, match *time.Time) (Todo, error) {
+			todo.CreatedAt = now
+			todo.UpdatedAt = now
+			return todo, nil
+		},
+	}
+
+	api := newApp(mockRepo)
+
+	t.Run("CreateTodo", func(t *testing.T) {
+		body := `{"title": "Learn SQL", "status": "INCOMPLETE"}`
+		r := httptest.NewRequest(http.MethodPost, "/todo", strings.NewReader(body))
+		w := httptest.NewRecorder()
+
+		web.HandlerFunc(api.createTodoHandler).ServeHTTP(w, r)
+
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body)
+		}
+		if got := w.Header().Get("Location"); got != "/todo/7" {
+			t.Errorf("Expected Location /todo/7, got %q", got)
+		}
+
+		var todo Todo
+		if err := json.Unmarshal(w.Body.Bytes(), &todo); err != nil {
+			t.Fatalf("Expected todo body, got %v", err)
+		}
+		if todo.ID != 7 || !todo.CreatedAt.Equal(now) || !todo.UpdatedAt.Equal(now) {
+			t.Errorf("Expected created todo, got %+v", todo)
+		}
+	})
+
+	t.Run("CreateTodoInvalid", func(t *testing.T) {
+		body := `{"title": "", "status": "DONE"}`
+		r := httptest.NewRequest(http.MethodPost, "/todo", strings.NewReader(body))
+		w := httptest.NewRecorder()
+
+		web.HandlerFunc(api.createTodoHandler).ServeHTTP(w, r)
+
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("CreateTodoTooLarge", func(t *testing.T) {
+		body := `{"title": "` + strings.Repeat("a", web.MaxBodyBytes) + `", "status": "INCOMPLETE"}`
+		r := httptest.NewRequest(http.MethodPost, "/todo", strings.NewReader(body))
+		w := httptest.NewRecorder()
+
+		web.HandlerFunc(api.createTodoHandler).ServeHTTP(w, r)
+
+		if w.Code != http.StatusRequestEntityTooLarge {
+			t.Fatalf("Expected status %d, got %d", http.StatusRequestEntityTooLarge, w.Code)
+		}
+	})
+
+	t.Run("UpdateTodo", func(t *testing.T) {
+		body := `{"title": "Learn SQL", "status": "COMPLETE"}`
+		r := httptest.NewRequest(http.MethodPut, "/todo/7", strings.NewReader(body))
+		r.SetPathValue("id", "7")
+		w := httptest.NewRecorder()
+
+		web.HandlerFunc(api.updateTodoHandler).ServeHTTP(w, r)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+		}
+
+		var todo Todo
+		if err := json.Unmarshal(w.Body.Bytes(), &todo); err != nil {
+			t.Fatalf("Expected todo body, got %v", err)
+		}
+		if todo.ID != 7 || !todo.Status.Equal(Complete) {
+			t.Errorf("Expected updated todo, got %+v", todo)
+		}
+	})
+
+	t.Run("NotFoundVersusInternal", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			err    error
+			status int
+		}{
+			{"NotFound", fmt.Errorf("todo with id 7: %w", ErrNotFound), http.StatusNotFound},
+			{"ConnectionFailure", errors.New("dial tcp: connection refused"), http.StatusInternalServerError},
+			{"DeadlineExceeded", fmt.Errorf("failed to get todo with id 7: %w", context.DeadlineExceeded), http.StatusGatewayTimeout},
+			{"SerializationFailure", fmt.Errorf("failed to update todo with id 7: %w", &pgconn.PgError{Code: "40001"}), http.StatusConflict},
+			{"UniqueViolation", fmt.Errorf("failed to update todo with id 7: %w", &pgconn.PgError{Code: "23505"}), http.StatusConflict},
+			{"StringTooLong", fmt.Errorf("failed to update todo with id 7: %w", &pgconn.PgError{Code: "22001"}), http.StatusBadRequest},
+			{"AdminShutdown", fmt.Errorf("failed to get todo with id 7: %w", &pgconn.PgError{Code: "57P01"}), http.StatusServiceUnavailable},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				api := newApp(&MockTodoRepository{
+					GetTodoByIDFunc: func(id int) (Todo, error) { return Todo{}, tt.err },
+					UpdateTodoFunc:  func(todo Todo, match *time.Time) (Todo, error) { return Todo{}, tt.err },
+					DeleteTodoFunc:  func(id int, match *time.Time) error { return tt.err },
+				})
+
+				handlers := map[string]web.HandlerFunc{
+					http.MethodGet:    api.getTodoByIDHandler,
+					http.MethodPut:    api.updateTodoHandler,
+					http.MethodDelete: api.deleteTodoHandler,
+				}
+
+				for method, h := range handlers {
+					r := httptest.NewRequest(method, "/todo/7", strings.NewReader(`{"title": "Learn SQL", "status": "COMPLETE"}`))
+					r.SetPathValue("id", "7")
+					w := httptest.NewRecorder()
+
+					h.ServeHTTP(w, r)
+
+					if w.Code != tt.status {
+						t.Errorf("%s: expected status %d, got %d", method, tt.status, w.Code)
+					}
+					if strings.Contains(w.Body.String(), "dial tcp") {
+						t.Errorf("%s: expected the driver error to be kept from the client, got %s", method, w.Body)
+					}
+				}
+			})
+		}
+	})
+}
+
+func Test_PatchTodo(t *testing.T) {
+	t.Parallel()
+
+	expires := time.Date(2024, 12, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		contentType string
+		patch       string
+		status      int
+		columns     []string
+	}{
+		{"MergePatchStatus", "application/merge-patch+json", `{"status": "COMPLETE"}`, http.StatusOK, []string{"status"}},
+		{"MergePatchClearExpiry", "application/merge-patch+json", `{"expired_at": null}`, http.StatusOK, []string{"expires_at"}},
+		{"MergePatchNoChange", "application/merge-patch+json", `{"title": "Learn SQL"}`, http.StatusOK, nil},
+		{"MergePatchInvalidStatus", "application/merge-patch+json", `{"status": "DONE"}`, http.StatusBadRequest, nil},
+		{"JSONPatchTitle", "application/json-patch+json", `[{"op": "replace", "path": "/title", "value": "Learn Go"}]`, http.StatusOK, []string{"title"}},
+		{"JSONPatchTestFailed", "application/json-patch+json", `[{"op": "test", "path": "/title", "value": "Other"}]`, http.StatusConflict, nil},
+		{"TooLarge", "application/merge-patch+json", `{"title": "` + strings.Repeat("a", web.MaxBodyBytes) + `"}`, http.StatusRequestEntityTooLarge, nil},
+		{"UnsupportedMediaType", "application/json", `{"status": "COMPLETE"}`, http.StatusUnsupportedMediaType, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var columns []string
+			api := newApp(&MockTodoRepository{
+				GetTodoByIDFunc: func(id int) (Todo, error) {
+					return Todo{ID: id, Title: "Learn SQL", Status: Incomplete, ExpiredAt: &expires}, nil
+				},
+				PatchTodoFunc: func(todo Todo, cols []string, match *time.Time) (Todo, error) {
+					columns = cols
+					return todo, nil
+				},
+			})
+
+			r := httptest.NewRequest(http.MethodPatch, "/todo/1", strings.NewReader(tt.patch))
+			r.Header.Set("Content-Type", tt.contentType)
+			r.SetPathValue("id", "1")
+			w := httptest.NewRecorder()
+
+			web.HandlerFunc(api.patchTodoHandler).ServeHTTP(w, r)
+
+			if w.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d: %s", tt.status, w.Code, w.Body)
+			}
+			if strings.Join(columns, ",") != strings.Join(tt.columns, ",") {
+				t.Errorf("Expected columns %v, got %v", tt.columns, columns)
+			}
+		})
+	}
+}
+
+func Test_ConditionalRequests(t *testing.T) {
+	t.Parallel()
+
+	current := Todo{ID: 1, Title: "Learn SQL", Status: Incomplete, UpdatedAt: time.Date(2024, 11, 16, 20, 25, 51, 0, time.UTC)}
+	stale := Todo{UpdatedAt: current.UpdatedAt.Add(-time.Minute)}
+
+	var matched *time.Time
+	api := newApp(&MockTodoRepository{
+		GetTodoByIDFunc: func(id int) (Todo, error) { return current, nil },
+		UpdateTodoFunc: func(todo Todo, match *time.Time) (Todo, error) {
+			matched = match
+			return todo, nil
+		},
+		DeleteTodoFunc: func(id int, match *time.Time) error { return nil },
+	})
+
+	tests := []struct {
+		name    string
+		method  string
+		handler web.HandlerFunc
+		header  string
+		value   string
+		status  int
+	}{
+		{"GetETag", http.MethodGet, api.getTodoByIDHandler, "", "", http.StatusOK},
+		{"GetNotModified", http.MethodGet, api.getTodoByIDHandler, "If-None-Match", current.ETag(), http.StatusNotModified},
+		{"GetModified", http.MethodGet, api.getTodoByIDHandler, "If-None-Match", stale.ETag(), http.StatusOK},
+		{"PutMatch", http.MethodPut, api.updateTodoHandler, "If-Match", current.ETag(), http.StatusOK},
+		{"PutMismatch", http.MethodPut, api.updateTodoHandler, "If-Match", stale.ETag(), http.StatusPreconditionFailed},
+		{"DeleteMismatch", http.MethodDelete, api.deleteTodoHandler, "If-Match", stale.ETag(), http.StatusPreconditionFailed},
+		{"DeleteAny", http.MethodDelete, api.deleteTodoHandler, "If-Match", "*", http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/todo/1", strings.NewReader(`{"title": "Learn SQL", "status": "COMPLETE"}`))
+			r.SetPathValue("id", "1")
+			if tt.header != "" {
+				r.Header.Set(tt.header, tt.value)
+			}
+			w := httptest.NewRecorder()
+
+			tt.handler.ServeHTTP(w, r)
+
+			if w.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d: %s", tt.status, w.Code, w.Body)
+			}
+			if tt.method == http.MethodGet && w.Header().Get("ETag") != current.ETag() {
+				t.Errorf("Expected ETag %s, got %q", current.ETag(), w.Header().Get("ETag"))
+			}
+		})
+	}
+
+	if matched == nil || !matched.Equal(current.UpdatedAt) {
+		t.Errorf("Expected conditional update on %v, got %v", current.UpdatedAt, matched)
+	}
+}
+
+func Test_ArchiveAndRestore(t *testing.T) {
+	t.Parallel()
+
+	api := newApp(&MockTodoRepository{
+		ArchiveTodoFunc: func(id int, archived bool, match *time.Time) (Todo, error) {
+			return Todo{ID: id, Title: "Learn SQL", Status: Incomplete, Archived: archived}, nil
+		},
+		RestoreTodoFunc: func(id int) (Todo, error) {
+			if id != 1 {
+				return Todo{}, fmt.Errorf("todo with id %d: %w", id, ErrNotFound)
+			}
+			return Todo{ID: id, Title: "Learn SQL", Status: Incomplete}, nil
+		},
+	})
+
+	tests := []struct {
+		name     string
+		handler  web.HandlerFunc
+		id       string
+		status   int
+		archived bool
+	}{
+		{"Archive", api.archiveTodoHandler, "1", http.StatusOK, true},
+		{"Unarchive", api.unarchiveTodoHandler, "1", http.StatusOK, false},
+		{"Restore", api.restoreTodoHandler, "1", http.StatusOK, false},
+		{"RestoreNotDeleted", api.restoreTodoHandler, "2", http.StatusNotFound, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/todo/"+tt.id, nil)
+			r.SetPathValue("id", tt.id)
+			w := httptest.NewRecorder()
+
+			tt.handler.ServeHTTP(w, r)
+
+			if w.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d: %s", tt.status, w.Code, w.Body)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			var todo Todo
+			if err := json.Unmarshal(w.Body.Bytes(), &todo); err != nil {
+				t.Fatalf("Expected todo body, got %v", err)
+			}
+			if todo.Archived != tt.archived {
+				t.Errorf("Expected archived %t, got %t", tt.archived, todo.Archived)
+			}
+		})
+	}
+}
@@ -1,11 +1,14 @@
 package todoapp
 
 import (
+	"context"
 	"fmt"
 	"testing"
 	"time"
 
 	"github.com/BuildFrom/Golang-Stdlib/internal/infrastructure/sqldb"
+	"github.com/BuildFrom/Golang-Stdlib/internal/sdk/order"
+	"github.com/BuildFrom/Golang-Stdlib/internal/sdk/page"
 )
 
 // NewTestTodoRepository is used for integration-style testing with a real database.
@@ -19,109 +22,175 @@ type testTodoRepository struct {
 	db sqldb.Service
 }
 
-func (r *testTodoRepository) getTodos() ([]Todo, error) {
+func (r *testTodoRepository) getTodos(ctx context.Context, filter QueryFilter, orderBy order.By, pg page.Page, after *cursor) ([]Todo, error) {
 	// You would normally interact with the db here.
 	return []Todo{
-		{ID: 1, Title: "Mock Todo 1", Status: "INCOMPLETE"},
-		{ID: 2, Title: "Mock Todo 2", Status: "COMPLETE"},
+		{ID: 1, Title: "Mock Todo 1", Status: Incomplete},
+		{ID: 2, Title: "Mock Todo 2", Status: Complete},
 	}, nil
 }
 
-func (r *testTodoRepository) getTodoByID(id int) (Todo, error) {
+func (r *testTodoRepository) countTodos(ctx context.Context, filter QueryFilter) (int, error) {
+	// You would normally interact with the db here.
+	return 2, nil
+}
+
+func (r *testTodoRepository) getTodoByID(ctx context.Context, id int) (Todo, error) {
 	// Return mock data based on the ID
 	if id == 1 {
-		return Todo{ID: 1, Title: "Mock Todo 1", Status: "INCOMPLETE"}, nil
+		return Todo{ID: 1, Title: "Mock Todo 1", Status: Incomplete}, nil
 	} else if id == 2 {
-		return Todo{ID: 2, Title: "Mock Todo 2", Status: "COMPLETE"}, nil
+		return Todo{ID: 2, Title: "Mock Todo 2", Status: Complete}, nil
 	}
 
-	return Todo{}, fmt.Errorf("Todo not found")
+	return Todo{}, ErrNotFound
 }
 
-func (r *testTodoRepository) createTodo(todo Todo) error {
+func (r *testTodoRepository) createTodo(ctx context.Context, todo Todo) (Todo, error) {
 	// Simulate saving the todo to the database
-	return nil
+	todo.ID = 3
+	return todo, nil
 }
 
-func (r *testTodoRepository) updateTodo(todo Todo) error {
+func (r *testTodoRepository) updateTodo(ctx context.Context, todo Todo, match *time.Time) (Todo, error) {
 	// Simulate updating the todo in the database
-	return nil
+	return todo, nil
 }
 
-func (r *testTodoRepository) deleteTodo(id int) error {
+func (r *testTodoRepository) patchTodo(ctx context.Context, todo Todo, columns []string, match *time.Time) (Todo, error) {
+	// Simulate patching the todo in the database
+	return todo, nil
+}
+
+func (r *testTodoRepository) deleteTodo(ctx context.Context, id int, match *time.Time) error {
 	// Simulate deleting a todo from the database
 	return nil
 }
 
+func (r *testTodoRepository) archiveTodo(ctx context.Context, id int, archived bool, match *time.Time) (Todo, error) {
+	// Simulate archiving the todo in the database
+	return Todo{ID: id, Title: "Mock Todo", Status: Incomplete, Archived: archived}, nil
+}
+
+func (r *testTodoRepository) restoreTodo(ctx context.Context, id int) (Todo, error) {
+	// Simulate restoring the todo in the database
+	return Todo{ID: id, Title: "Mock Todo", Status: Incomplete}, nil
+}
+
+func (r *testTodoRepository) inTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	// Simulate running fn in a transaction
+	return fn(ctx)
+}
+
 type MockTodoRepository struct {
-	GetTodosFunc    func() ([]Todo, error)
+	GetTodosFunc    func(filter QueryFilter, orderBy order.By, pg page.Page, after *cursor) ([]Todo, error)
+	CountTodosFunc  func(filter QueryFilter) (int, error)
 	GetTodoByIDFunc func(id int) (Todo, error)
-	CreateTodoFunc  func(todo Todo) error
-	UpdateTodoFunc  func(todo Todo) error
-	DeleteTodoFunc  func(id int) error
+	CreateTodoFunc  func(todo Todo) (Todo, error)
+	UpdateTodoFunc  func(todo Todo, match *time.Time) (Todo, error)
+	PatchTodoFunc   func(todo Todo, columns []string, match *time.Time) (Todo, error)
+	DeleteTodoFunc  func(id int, match *time.Time) error
+	ArchiveTodoFunc func(id int, archived bool, match *time.Time) (Todo, error)
+	RestoreTodoFunc func(id int) (Todo, error)
 }
 
-func (m *MockTodoRepository) getTodos() ([]Todo, error) {
+func (m *MockTodoRepository) getTodos(ctx context.Context, filter QueryFilter, orderBy order.By, pg page.Page, after *cursor) ([]Todo, error) {
 	if m.GetTodosFunc != nil {
-		return m.GetTodosFunc()
+		return m.GetTodosFunc(filter, orderBy, pg, after)
 	}
 	return nil, fmt.Errorf("GetTodosFunc not implemented")
 }
 
-func (m *MockTodoRepository) getTodoByID(id int) (Todo, error) {
+func (m *MockTodoRepository) countTodos(ctx context.Context, filter QueryFilter) (int, error) {
+	if m.CountTodosFunc != nil {
+		return m.CountTodosFunc(filter)
+	}
+	return 0, fmt.Errorf("CountTodosFunc not implemented")
+}
+
+func (m *MockTodoRepository) getTodoByID(ctx context.Context, id int) (Todo, error) {
 	if m.GetTodoByIDFunc != nil {
 		return m.GetTodoByIDFunc(id)
 	}
 	return Todo{}, fmt.Errorf("GetTodoByIDFunc not implemented")
 }
 
-func (m *MockTodoRepository) createTodo(todo Todo) error {
+func (m *MockTodoRepository) createTodo(ctx context.Context, todo Todo) (Todo, error) {
 	if m.CreateTodoFunc != nil {
 		return m.CreateTodoFunc(todo)
 	}
-	return fmt.Errorf("CreateTodoFunc not implemented")
+	return Todo{}, fmt.Errorf("CreateTodoFunc not implemented")
 }
 
-func (m *MockTodoRepository) updateTodo(todo Todo) error {
+func (m *MockTodoRepository) updateTodo(ctx context.Context, todo Todo, match *time.Time) (Todo, error) {
 	if m.UpdateTodoFunc != nil {
-		return m.UpdateTodoFunc(todo)
+		return m.UpdateTodoFunc(todo, match)
+	}
+	return Todo{}, fmt.Errorf("UpdateTodoFunc not implemented")
+}
+
+func (m *MockTodoRepository) patchTodo(ctx context.Context, todo Todo, columns []string, match *time.Time) (Todo, error) {
+	if m.PatchTodoFunc != nil {
+		return m.PatchTodoFunc(todo, columns, match)
 	}
-	return fmt.Errorf("UpdateTodoFunc not implemented")
+	return Todo{}, fmt.Errorf("PatchTodoFunc not implemented")
 }
 
-func (m *MockTodoRepository) deleteTodo(id int) error {
+func (m *MockTodoRepository) deleteTodo(ctx context.Context, id int, match *time.Time) error {
 	if m.DeleteTodoFunc != nil {
-		return m.DeleteTodoFunc(id)
+		return m.DeleteTodoFunc(id, match)
 	}
 	return fmt.Errorf("DeleteTodoFunc not implemented")
 }
 
+func (m *MockTodoRepository) archiveTodo(ctx context.Context, id int, archived bool, match *time.Time) (Todo, error) {
+	if m.ArchiveTodoFunc != nil {
+		return m.ArchiveTodoFunc(id, archived, match)
+	}
+	return Todo{}, fmt.Errorf("ArchiveTodoFunc not implemented")
+}
+
+func (m *MockTodoRepository) restoreTodo(ctx context.Context, id int) (Todo, error) {
+	if m.RestoreTodoFunc != nil {
+		return m.RestoreTodoFunc(id)
+	}
+	return Todo{}, fmt.Errorf("RestoreTodoFunc not implemented")
+}
+
+func (m *MockTodoRepository) inTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
 func Test_Todo(t *testing.T) {
 	t.Parallel()
 
 	// Create a new environment with a mock repository
 	mockRepo := &MockTodoRepository{
-		GetTodosFunc: func() ([]Todo, error) {
+		GetTodosFunc: func(filter QueryFilter, orderBy order.By, pg page.Page, after *cursor) ([]Todo, error) {
 			return []Todo{
-				{ID: 1, Title: "Mock Todo 1", Status: "INCOMPLETE"},
-				{ID: 2, Title: "Mock Todo 2", Status: "COMPLETE"},
+				{ID: 1, Title: "Mock Todo 1", Status: Incomplete},
+				{ID: 2, Title: "Mock Todo 2", Status: Complete},
 			}, nil
 		},
+		CountTodosFunc: func(filter QueryFilter) (int, error) {
+			return 2, nil
+		},
 		GetTodoByIDFunc: func(id int) (Todo, error) {
 			if id == 1 {
-				return Todo{ID: 1, Title: "Mock Todo 1", Status: "INCOMPLETE"}, nil
+				return Todo{ID: 1, Title: "Mock Todo 1", Status: Incomplete}, nil
 			} else if id == 2 {
-				return Todo{ID: 2, Title: "Mock Todo 2", Status: "COMPLETE"}, nil
+				return Todo{ID: 2, Title: "Mock Todo 2", Status: Complete}, nil
 			}
-			return Todo{}, fmt.Errorf("Todo not found")
+			return Todo{}, ErrNotFound
 		},
-		CreateTodoFunc: func(todo Todo) error {
-			return nil // Simulate successful creation
+		CreateTodoFunc: func(todo Todo) (Todo, error) {
+			todo.ID = 3
+			return todo, nil // Simulate successful creation
 		},
-		UpdateTodoFunc: func(todo Todo) error {
-			return nil // Simulate successful update
+		UpdateTodoFunc: func(todo Todo, match *time.Time) (Todo, error) {
+			return todo, nil // Simulate successful update
 		},
-		DeleteTodoFunc: func(id int) error {
+		DeleteTodoFunc: func(id int, match *time.Time) error {
 			return nil // Simulate successful delete
 		},
 	}
@@ -148,13 +217,21 @@ func Test_Todo(t *testing.T) {
 func testGetTodos(repo TodoRepository) func(t *testing.T) {
 	return func(t *testing.T) {
 		expected := 2
-		todos, err := repo.getTodos()
+		todos, err := repo.getTodos(context.Background(), QueryFilter{}, defaultOrderBy, page.MustParse("1", "10"), nil)
 		if err != nil {
 			t.Errorf("Expected no error, got %v", err)
 		}
 		if len(todos) != expected {
 			t.Errorf("Expected %d todos, got %d", expected, len(todos))
 		}
+
+		total, err := repo.countTodos(context.Background(), QueryFilter{})
+		if err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+		if total != expected {
+			t.Errorf("Expected total %d, got %d", expected, total)
+		}
 	}
 }
 
@@ -173,7 +250,7 @@ func testGetTodoByID(repo TodoRepository) func(t *testing.T) {
 
 		for _, tt := range tests {
 			t.Run(fmt.Sprintf("Get Todo by ID %d", tt.id), func(t *testing.T) {
-				todo, err := repo.getTodoByID(tt.id)
+				todo, err := repo.getTodoByID(context.Background(), tt.id)
 				if tt.err && err == nil {
 					t.Errorf("Expected error, got nil")
 				}
@@ -193,14 +270,18 @@ func testCreateTodo(repo TodoRepository) func(t *testing.T) {
 	return func(t *testing.T) {
 		todo := Todo{
 			Title:     "New Todo",
-			Status:    "INCOMPLETE",
+			Status:    Incomplete,
 			ExpiredAt: parseTime("2024-12-10"),
 			CreatedAt: *parseTime("2024-11-22"),
 			UpdatedAt: *parseTime("2024-11-22"),
 		}
-		if err := repo.createTodo(todo); err != nil {
+		created, err := repo.createTodo(context.Background(), todo)
+		if err != nil {
 			t.Errorf("Expected no error, got %v", err)
 		}
+		if created.ID == 0 {
+			t.Errorf("Expected created todo to have an ID")
+		}
 	}
 }
 
@@ -210,14 +291,18 @@ func testUpdateTodo(repo TodoRepository) func(t *testing.T) {
 		todo := Todo{
 			ID:        1,
 			Title:     "Updated Todo",
-			Status:    "COMPLETE",
+			Status:    Complete,
 			ExpiredAt: parseTime("2024-12-15"),
 			CreatedAt: *parseTime("2024-11-01"),
 			UpdatedAt: *parseTime("2024-11-01"),
 		}
-		if err := repo.updateTodo(todo); err != nil {
+		updated, err := repo.updateTodo(context.Background(), todo, nil)
+		if err != nil {
 			t.Errorf("Expected no error, got %v", err)
 		}
+		if updated.ID != todo.ID {
+			t.Errorf("Expected ID %d, got %d", todo.ID, updated.ID)
+		}
 	}
 }
 
@@ -228,7 +313,7 @@ func testDeleteTodo(repo TodoRepository) func(t *testing.T) {
 
 		for _, id := range tests {
 			t.Run(fmt.Sprintf("Delete Todo %d", id), func(t *testing.T) {
-				if err := repo.deleteTodo(id); err != nil {
+				if err := repo.deleteTodo(context.Background(), id, nil); err != nil {
 					t.Errorf("Expected no error, got %v", err)
 				}
 			})
@@ -1,7 +1,10 @@
 // Package todostatus represents the status of a todo in the system.
 package todoapp
 
-import "fmt"
+import (
+	"database/sql/driver"
+	"fmt"
+)
 
 // The set of status types that can be used.
 var (
@@ -40,6 +43,40 @@ func (st Status) MarshalText() ([]byte, error) {
 	return []byte(st.value), nil
 }
 
+// UnmarshalText provides support for JSON decoding. Only known status
+// types are accepted.
+func (st *Status) UnmarshalText(data []byte) error {
+	typ, err := Parse(string(data))
+	if err != nil {
+		return err
+	}
+
+	*st = typ
+	return nil
+}
+
+// Scan implements the sql.Scanner interface so a status can be read
+// directly from the database.
+func (st *Status) Scan(src any) error {
+	switch v := src.(type) {
+	case string:
+		return st.UnmarshalText([]byte(v))
+	case []byte:
+		return st.UnmarshalText(v)
+	default:
+		return fmt.Errorf("cannot scan %T into status", src)
+	}
+}
+
+// Value implements the driver.Valuer interface so a status can be written
+// directly to the database.
+func (st Status) Value() (driver.Value, error) {
+	if _, exists := statusTypes[st.value]; !exists {
+		return nil, fmt.Errorf("invalid status type %q", st.value)
+	}
+	return st.value, nil
+}
+
 // =============================================================================
 
 // Parse parses the string value and returns a status type if one exists.
@@ -1,14 +1,19 @@
 package server
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
 	"strconv"
 	"time"
 
 	"github.com/BuildFrom/Golang-Stdlib/cmd/api/build/all"
+	"github.com/BuildFrom/Golang-Stdlib/internal/app/todoapp"
 	"github.com/BuildFrom/Golang-Stdlib/internal/infrastructure/sqldb"
+	"github.com/BuildFrom/Golang-Stdlib/internal/sdk/health"
+	mw "github.com/BuildFrom/Golang-Stdlib/internal/sdk/middleware"
 	_ "github.com/joho/godotenv/autoload"
 )
 
@@ -17,21 +22,43 @@ type Server struct {
 	db   sqldb.Service
 }
 
-func NewServer() *http.Server {
+// NewServer builds the HTTP server around the given database. The caller
+// owns db and is responsible for closing it after the server has shut down.
+// The health checks are registered in checks, which the caller drains when
+// shutdown begins. cors configures the cross-origin requests the API accepts.
+// Requests, handler errors and background jobs are logged to log.
+func NewServer(log *slog.Logger, db sqldb.Service, checks *health.Registry, cors mw.CORSConfig) *http.Server {
 	port, _ := strconv.Atoi(os.Getenv("PORT"))
 	NewServer := &Server{
 		port: port,
-		db:   sqldb.New(),
+		db:   db,
 	}
 
 	// Declare Server config
 	server := &http.Server{
 		Addr:         fmt.Sprintf(":%d", NewServer.port),
-		Handler:      all.RegisterRoutes(NewServer.db),
+		Handler:      all.RegisterRoutes(log, NewServer.db, checks, cors),
 		IdleTimeout:  time.Minute,
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 30 * time.Second,
+		ErrorLog:     slog.NewLogLogger(log.Handler(), slog.LevelError),
 	}
 
+	// Start the job that removes soft deleted todos once they are past the
+	// retention period. It stops when the server shuts down.
+	ctx, cancel := context.WithCancel(context.Background())
+	go todoapp.RunPurge(ctx, log, NewServer.db, envDuration("TODO_PURGE_INTERVAL", time.Hour), envDuration("TODO_PURGE_RETENTION", 30*24*time.Hour))
+	server.RegisterOnShutdown(cancel)
+
 	return server
 }
+
+// envDuration reads a duration from the environment, falling back to def
+// when it is unset or invalid.
+func envDuration(key string, def time.Duration) time.Duration {
+	d, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || d <= 0 {
+		return def
+	}
+	return d
+}
@@ -0,0 +1,394 @@
+package sqldb
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Default pool settings used when the environment does not provide them.
+const (
+	DefaultMaxOpenConns    = 25
+	DefaultMaxIdleConns    = 25
+	DefaultConnMaxLifetime = 30 * time.Minute
+	DefaultConnMaxIdleTime = 5 * time.Minute
+
+	DefaultReplicaCheckInterval = 5 * time.Second
+	DefaultSlowQueryThreshold   = 500 * time.Millisecond
+)
+
+var sslModes = map[string]bool{
+	"disable":     true,
+	"allow":       true,
+	"prefer":      true,
+	"require":     true,
+	"verify-ca":   true,
+	"verify-full": true,
+}
+
+var drivers = map[string]bool{
+	DriverStdlib:  true,
+	DriverPgxPool: true,
+}
+
+// Config holds the settings used to connect to the database and size the
+// connection pool. When URL is set it is used as the base connection string
+// and the individual connection fields are ignored; the remaining options
+// are still applied on top of it.
+type Config struct {
+	// Driver selects the backend, DriverStdlib when empty.
+	Driver string
+
+	URL string
+
+	Host     string
+	Port     string
+	User     string
+	Password string
+	Database string
+
+	// Schema sets the search_path of every connection.
+	Schema string
+
+	// SSLMode is one of disable, allow, prefer, require, verify-ca or
+	// verify-full. The CA and client certificate paths are optional.
+	SSLMode     string
+	SSLRootCert string
+	SSLCert     string
+	SSLKey      string
+
+	ApplicationName  string
+	StatementTimeout time.Duration
+
+	MaxOpenConns int
+
+	// MaxIdleConns is ignored by DriverPgxPool, which keeps idle
+	// connections until ConnMaxIdleTime.
+	MaxIdleConns int
+
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+
+	// ReplicaURLs lists read replicas. Replicas share every other option
+	// with the primary and inherit its credentials when their URL has none.
+	ReplicaURLs          []string
+	ReplicaCheckInterval time.Duration
+
+	// Logger receives the service's own messages, slog.Default when nil.
+	Logger *slog.Logger
+
+	// SlowQueryThreshold logs statements and transactions that take at
+	// least this long. Zero disables slow query logging.
+	SlowQueryThreshold time.Duration
+
+	// RedactArgs replaces query arguments with a placeholder before they
+	// reach the hooks, for queries that carry personal data or secrets.
+	RedactArgs bool
+
+	// Hooks observe every statement and transaction after the built-in
+	// metrics and slow query hooks.
+	Hooks []Hook
+
+	// QueryComments appends the request and trace IDs carried by the
+	// context to each statement as a comment, so they show up in
+	// pg_stat_activity and the server logs. ConfigFromEnv turns it on;
+	// BLUEPRINT_DB_QUERY_COMMENTS=false turns it off. Every tagged
+	// statement text is unique, which defeats the prepared statement cache.
+	QueryComments bool
+}
+
+// ConfigFromEnv builds a Config from DATABASE_URL and the BLUEPRINT_DB_*
+// environment variables.
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{
+		Driver:          os.Getenv("BLUEPRINT_DB_DRIVER"),
+		URL:             os.Getenv("DATABASE_URL"),
+		Host:            os.Getenv("BLUEPRINT_DB_HOST"),
+		Port:            os.Getenv("BLUEPRINT_DB_PORT"),
+		User:            os.Getenv("BLUEPRINT_DB_USERNAME"),
+		Password:        os.Getenv("BLUEPRINT_DB_PASSWORD"),
+		Database:        os.Getenv("BLUEPRINT_DB_DATABASE"),
+		Schema:          os.Getenv("BLUEPRINT_DB_SCHEMA"),
+		SSLMode:         os.Getenv("BLUEPRINT_DB_SSLMODE"),
+		SSLRootCert:     os.Getenv("BLUEPRINT_DB_SSLROOTCERT"),
+		SSLCert:         os.Getenv("BLUEPRINT_DB_SSLCERT"),
+		SSLKey:          os.Getenv("BLUEPRINT_DB_SSLKEY"),
+		ApplicationName: os.Getenv("BLUEPRINT_DB_APPLICATION_NAME"),
+		MaxOpenConns:    DefaultMaxOpenConns,
+		MaxIdleConns:    DefaultMaxIdleConns,
+		ConnMaxLifetime: DefaultConnMaxLifetime,
+		ConnMaxIdleTime: DefaultConnMaxIdleTime,
+
+		ReplicaCheckInterval: DefaultReplicaCheckInterval,
+		SlowQueryThreshold:   DefaultSlowQueryThreshold,
+		QueryComments:        true,
+	}
+
+	for _, replica := range strings.Split(os.Getenv("BLUEPRINT_DB_REPLICA_URLS"), ",") {
+		if replica = strings.TrimSpace(replica); replica != "" {
+			cfg.ReplicaURLs = append(cfg.ReplicaURLs, replica)
+		}
+	}
+
+	var errs []error
+
+	parseInt := func(key string, dst *int) {
+		value := os.Getenv(key)
+		if value == "" {
+			return
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+			return
+		}
+		*dst = n
+	}
+
+	parseDuration := func(key string, dst *time.Duration) {
+		value := os.Getenv(key)
+		if value == "" {
+			return
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+			return
+		}
+		*dst = d
+	}
+
+	parseBool := func(key string, dst *bool) {
+		value := os.Getenv(key)
+		if value == "" {
+			return
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+			return
+		}
+		*dst = b
+	}
+
+	parseInt("BLUEPRINT_DB_MAX_OPEN_CONNS", &cfg.MaxOpenConns)
+	parseInt("BLUEPRINT_DB_MAX_IDLE_CONNS", &cfg.MaxIdleConns)
+	parseDuration("BLUEPRINT_DB_CONN_MAX_LIFETIME", &cfg.ConnMaxLifetime)
+	parseDuration("BLUEPRINT_DB_CONN_MAX_IDLE_TIME", &cfg.ConnMaxIdleTime)
+	parseDuration("BLUEPRINT_DB_STATEMENT_TIMEOUT", &cfg.StatementTimeout)
+	parseDuration("BLUEPRINT_DB_REPLICA_CHECK_INTERVAL", &cfg.ReplicaCheckInterval)
+	parseDuration("BLUEPRINT_DB_SLOW_QUERY_THRESHOLD", &cfg.SlowQueryThreshold)
+	parseBool("BLUEPRINT_DB_REDACT_ARGS", &cfg.RedactArgs)
+	parseBool("BLUEPRINT_DB_QUERY_COMMENTS", &cfg.QueryComments)
+
+	// The idle default follows a smaller pool, so only an explicit
+	// BLUEPRINT_DB_MAX_IDLE_CONNS can exceed BLUEPRINT_DB_MAX_OPEN_CONNS.
+	if os.Getenv("BLUEPRINT_DB_MAX_IDLE_CONNS") == "" && cfg.MaxOpenConns > 0 {
+		cfg.MaxIdleConns = min(cfg.MaxIdleConns, cfg.MaxOpenConns)
+	}
+
+	if len(errs) > 0 {
+		return Config{}, fmt.Errorf("sqldb: config: %w", errors.Join(errs...))
+	}
+
+	return cfg, nil
+}
+
+// Validate checks the configuration is complete and consistent.
+func (c Config) Validate() error {
+	var errs []error
+
+	if c.URL != "" {
+		u, err := url.Parse(c.URL)
+		switch {
+		case err != nil:
+			errs = append(errs, fmt.Errorf("DATABASE_URL: %w", err))
+		case u.Scheme != "postgres" && u.Scheme != "postgresql":
+			errs = append(errs, fmt.Errorf("DATABASE_URL: unsupported scheme %q", u.Scheme))
+		}
+	} else {
+		required := []struct {
+			name  string
+			value string
+		}{
+			{"host", c.Host},
+			{"port", c.Port},
+			{"user", c.User},
+			{"database", c.Database},
+		}
+		for _, r := range required {
+			if r.value == "" {
+				errs = append(errs, fmt.Errorf("%s is required when DATABASE_URL is not set", r.name))
+			}
+		}
+
+		if c.Port != "" {
+			if _, err := strconv.Atoi(c.Port); err != nil {
+				errs = append(errs, fmt.Errorf("port %q is not a number", c.Port))
+			}
+		}
+	}
+
+	for _, replica := range c.ReplicaURLs {
+		u, err := url.Parse(replica)
+		switch {
+		case err != nil:
+			errs = append(errs, fmt.Errorf("replica: %w", err))
+		case u.Scheme != "postgres" && u.Scheme != "postgresql":
+			errs = append(errs, fmt.Errorf("replica %s: unsupported scheme %q", u.Redacted(), u.Scheme))
+		case u.Host == "":
+			errs = append(errs, fmt.Errorf("replica %s: missing host", u.Redacted()))
+		}
+	}
+
+	if c.Driver != "" && !drivers[c.Driver] {
+		errs = append(errs, fmt.Errorf("unknown driver %q", c.Driver))
+	}
+
+	if c.SSLMode != "" && !sslModes[c.SSLMode] {
+		errs = append(errs, fmt.Errorf("unknown sslmode %q", c.SSLMode))
+	}
+
+	if (c.SSLMode == "verify-ca" || c.SSLMode == "verify-full") && c.SSLRootCert == "" {
+		errs = append(errs, fmt.Errorf("sslmode %s requires a root certificate", c.SSLMode))
+	}
+
+	if (c.SSLCert == "") != (c.SSLKey == "") {
+		errs = append(errs, errors.New("client certificate and key must be set together"))
+	}
+
+	for _, path := range []string{c.SSLRootCert, c.SSLCert, c.SSLKey} {
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			errs = append(errs, fmt.Errorf("certificate file: %w", err))
+		}
+	}
+
+	if c.MaxOpenConns < 0 {
+		errs = append(errs, errors.New("max open connections must not be negative"))
+	}
+
+	if c.MaxIdleConns < 0 {
+		errs = append(errs, errors.New("max idle connections must not be negative"))
+	}
+
+	if c.MaxOpenConns > 0 && c.MaxIdleConns > c.MaxOpenConns {
+		errs = append(errs, fmt.Errorf("max idle connections (%d) exceeds max open connections (%d)", c.MaxIdleConns, c.MaxOpenConns))
+	}
+
+	if c.ConnMaxLifetime < 0 || c.ConnMaxIdleTime < 0 || c.StatementTimeout < 0 || c.ReplicaCheckInterval < 0 || c.SlowQueryThreshold < 0 {
+		errs = append(errs, errors.New("durations must not be negative"))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("sqldb: invalid config: %w", errors.Join(errs...))
+	}
+
+	return nil
+}
+
+// DSN returns the connection string for the configuration. Options are
+// passed as URL parameters so they are escaped correctly.
+func (c Config) DSN() (string, error) {
+	var u *url.URL
+
+	if c.URL != "" {
+		var err error
+		u, err = url.Parse(c.URL)
+		if err != nil {
+			return "", fmt.Errorf("sqldb: parse DATABASE_URL: %w", err)
+		}
+	} else {
+		u = &url.URL{
+			Scheme: "postgres",
+			User:   url.UserPassword(c.User, c.Password),
+			Host:   net.JoinHostPort(c.Host, c.Port),
+			Path:   "/" + c.Database,
+		}
+	}
+
+	q := u.Query()
+
+	set := func(key string, value string) {
+		if value != "" {
+			q.Set(key, value)
+		}
+	}
+
+	// Keep the historical default of no TLS for the discrete variables, but
+	// let a DATABASE_URL decide for itself.
+	switch {
+	case c.SSLMode != "":
+		q.Set("sslmode", c.SSLMode)
+	case c.URL == "":
+		q.Set("sslmode", "disable")
+	}
+
+	set("sslrootcert", c.SSLRootCert)
+	set("sslcert", c.SSLCert)
+	set("sslkey", c.SSLKey)
+	set("search_path", c.Schema)
+	set("application_name", c.ApplicationName)
+
+	if c.StatementTimeout > 0 {
+		q.Set("statement_timeout", strconv.FormatInt(c.StatementTimeout.Milliseconds(), 10))
+	}
+
+	u.RawQuery = q.Encode()
+
+	return u.String(), nil
+}
+
+// logger returns the logger the service writes to.
+func (c Config) logger() *slog.Logger {
+	if c.Logger != nil {
+		return c.Logger
+	}
+	return slog.Default()
+}
+
+// replicaConfigs returns a configuration per replica, sharing every option
+// with the primary but its URL. Credentials and the database name missing
+// from a replica URL are taken from the primary.
+func (c Config) replicaConfigs() ([]Config, error) {
+	configs := make([]Config, 0, len(c.ReplicaURLs))
+
+	for _, replica := range c.ReplicaURLs {
+		u, err := url.Parse(replica)
+		if err != nil {
+			return nil, fmt.Errorf("sqldb: parse replica: %w", err)
+		}
+
+		primary := &url.URL{
+			User: url.UserPassword(c.User, c.Password),
+			Path: "/" + c.Database,
+		}
+		if c.URL != "" {
+			if primary, err = url.Parse(c.URL); err != nil {
+				return nil, fmt.Errorf("sqldb: parse DATABASE_URL: %w", err)
+			}
+		}
+
+		if u.User == nil {
+			u.User = primary.User
+		}
+		if u.Path == "" || u.Path == "/" {
+			u.Path = primary.Path
+		}
+
+		rc := c
+		rc.URL = u.String()
+		rc.ReplicaURLs = nil
+		configs = append(configs, rc)
+	}
+
+	return configs, nil
+}
@@ -0,0 +1,178 @@
+package sqldb
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestConfigDSN(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      Config
+		expected map[string]string
+	}{
+		{
+			name: "Fields",
+			cfg: Config{
+				Host:             "localhost",
+				Port:             "5432",
+				User:             "user",
+				Password:         "p@ss word",
+				Database:         "todos",
+				Schema:           "public",
+				ApplicationName:  "api",
+				StatementTimeout: 2 * time.Second,
+			},
+			expected: map[string]string{
+				"sslmode":           "disable",
+				"search_path":       "public",
+				"application_name":  "api",
+				"statement_timeout": "2000",
+			},
+		},
+		{
+			name: "URL",
+			cfg: Config{
+				URL:    "postgres://user:pass@db:5432/todos?sslmode=require",
+				Schema: "app",
+			},
+			expected: map[string]string{
+				"sslmode":     "require",
+				"search_path": "app",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dsn, err := tt.cfg.DSN()
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			u, err := url.Parse(dsn)
+			if err != nil {
+				t.Fatalf("expected a valid URL, got %v", err)
+			}
+
+			for k, v := range tt.expected {
+				if got := u.Query().Get(k); got != v {
+					t.Errorf("expected %s to be %q, got %q", k, v, got)
+				}
+			}
+		})
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	valid := Config{
+		Host:         "localhost",
+		Port:         "5432",
+		User:         "user",
+		Database:     "todos",
+		MaxOpenConns: 10,
+		MaxIdleConns: 5,
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*Config)
+		err    bool
+	}{
+		{"Valid", func(c *Config) {}, false},
+		{"URLOnly", func(c *Config) { *c = Config{URL: "postgres://user@db/todos"} }, false},
+		{"BadScheme", func(c *Config) { *c = Config{URL: "mysql://user@db/todos"} }, true},
+		{"MissingHost", func(c *Config) { c.Host = "" }, true},
+		{"BadPort", func(c *Config) { c.Port = "abc" }, true},
+		{"UnknownSSLMode", func(c *Config) { c.SSLMode = "strict" }, true},
+		{"VerifyWithoutCA", func(c *Config) { c.SSLMode = "verify-full" }, true},
+		{"CertWithoutKey", func(c *Config) { c.SSLCert = "client.crt" }, true},
+		{"IdleExceedsOpen", func(c *Config) { c.MaxIdleConns = 20 }, true},
+		{"NegativeLifetime", func(c *Config) { c.ConnMaxLifetime = -time.Second }, true},
+		{"Replica", func(c *Config) { c.ReplicaURLs = []string{"postgres://replica:5432"} }, false},
+		{"ReplicaBadScheme", func(c *Config) { c.ReplicaURLs = []string{"mysql://replica"} }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid
+			tt.modify(&cfg)
+
+			err := cfg.Validate()
+			if tt.err && err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if !tt.err && err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("BLUEPRINT_DB_MAX_OPEN_CONNS", "40")
+	t.Setenv("BLUEPRINT_DB_CONN_MAX_LIFETIME", "1h")
+	t.Setenv("BLUEPRINT_DB_SLOW_QUERY_THRESHOLD", "250ms")
+	t.Setenv("BLUEPRINT_DB_REDACT_ARGS", "true")
+
+	cfg, err := ConfigFromEnv()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if cfg.MaxOpenConns != 40 {
+		t.Errorf("expected 40 max open connections, got %d", cfg.MaxOpenConns)
+	}
+	if cfg.ConnMaxLifetime != time.Hour {
+		t.Errorf("expected 1h max lifetime, got %s", cfg.ConnMaxLifetime)
+	}
+	if cfg.SlowQueryThreshold != 250*time.Millisecond {
+		t.Errorf("expected 250ms slow query threshold, got %s", cfg.SlowQueryThreshold)
+	}
+	if !cfg.RedactArgs {
+		t.Error("expected arguments to be redacted")
+	}
+	if !cfg.QueryComments {
+		t.Error("expected query comments by default")
+	}
+
+	t.Setenv("BLUEPRINT_DB_QUERY_COMMENTS", "false")
+	if cfg, err := ConfigFromEnv(); err != nil || cfg.QueryComments {
+		t.Errorf("expected query comments to be turned off, got %t, %v", cfg.QueryComments, err)
+	}
+
+	t.Setenv("BLUEPRINT_DB_MAX_IDLE_CONNS", "many")
+	if _, err := ConfigFromEnv(); err == nil {
+		t.Error("expected error for a non numeric pool size")
+	}
+}
+
+func TestConfigFromEnvPoolSize(t *testing.T) {
+	t.Setenv("BLUEPRINT_DB_HOST", "localhost")
+	t.Setenv("BLUEPRINT_DB_PORT", "5432")
+	t.Setenv("BLUEPRINT_DB_USERNAME", "user")
+	t.Setenv("BLUEPRINT_DB_DATABASE", "todos")
+	t.Setenv("BLUEPRINT_DB_MAX_OPEN_CONNS", "10")
+
+	cfg, err := ConfigFromEnv()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.MaxIdleConns != 10 {
+		t.Errorf("expected the idle default to follow max open connections, got %d", cfg.MaxIdleConns)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected only max open connections to be valid, got %v", err)
+	}
+
+	t.Setenv("BLUEPRINT_DB_MAX_IDLE_CONNS", "25")
+
+	cfg, err = ConfigFromEnv()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for explicit idle connections over max open connections")
+	}
+}
@@ -2,97 +2,116 @@ package sqldb
 
 import (
 	"context"
-	"log"
 	"testing"
-	"time"
 
-	"github.com/testcontainers/testcontainers-go"
-	"github.com/testcontainers/testcontainers-go/modules/postgres"
-	"github.com/testcontainers/testcontainers-go/wait"
+	"github.com/BuildFrom/Golang-Stdlib/internal/infrastructure/sqldb/sqldbtest"
+	"github.com/BuildFrom/Golang-Stdlib/internal/sdk/health"
 )
 
-func mustStartPostgresContainer() (func(context.Context) error, error) {
-	var (
-		dbName = "database"
-		dbPwd  = "password"
-		dbUser = "user"
-	)
-
-	dbContainer, err := postgres.Run(
-		context.Background(),
-		"postgres:latest",
-		postgres.WithDatabase(dbName),
-		postgres.WithUsername(dbUser),
-		postgres.WithPassword(dbPwd),
-		testcontainers.WithWaitStrategy(
-			wait.ForLog("database system is ready to accept connections").
-				WithOccurrence(2).
-				WithStartupTimeout(5*time.Second)),
-	)
-	if err != nil {
-		return nil, err
-	}
+// testConfig points at the container started by TestMain. It stays empty
+// when sqldbtest.SkipEnv is set and no container could be started, in which
+// case the database tests are skipped.
+var testConfig Config
+
+func TestMain(m *testing.M) {
+	sqldbtest.Main(m, func(c *sqldbtest.Container) {
+		testConfig = Config{
+			Host:         c.Host,
+			Port:         c.Port,
+			Database:     c.Database,
+			User:         c.User,
+			Password:     c.Password,
+			MaxOpenConns: DefaultMaxOpenConns,
+			MaxIdleConns: DefaultMaxIdleConns,
+		}
+	})
+}
 
-	database = dbName
-	password = dbPwd
-	username = dbUser
+// openTestDB opens a pool of its own against the test container and closes
+// it when the test finishes.
+func openTestDB(t *testing.T) Service {
+	t.Helper()
 
-	dbHost, err := dbContainer.Host(context.Background())
-	if err != nil {
-		return dbContainer.Terminate, err
+	if testConfig.Host == "" {
+		t.Skip("postgres container not available")
 	}
 
-	dbPort, err := dbContainer.MappedPort(context.Background(), "5432/tcp")
+	srv, err := Open(testConfig)
 	if err != nil {
-		return dbContainer.Terminate, err
+		t.Fatalf("Open() returned error: %v", err)
 	}
+	t.Cleanup(func() { srv.Close() })
 
-	host = dbHost
-	port = dbPort.Port()
-
-	return dbContainer.Terminate, err
+	return srv
 }
 
-func TestMain(m *testing.M) {
-	teardown, err := mustStartPostgresContainer()
-	if err != nil {
-		log.Fatalf("could not start postgres container: %v", err)
+func TestOpen(t *testing.T) {
+	srv := openTestDB(t)
+	if srv == nil {
+		t.Fatal("Open() returned nil")
 	}
+}
 
-	m.Run()
+func TestOpenIndependentPools(t *testing.T) {
+	first := openTestDB(t)
+	second := openTestDB(t)
 
-	if teardown != nil && teardown(context.Background()) != nil {
-		log.Fatalf("could not teardown postgres container: %v", err)
+	if err := first.Close(); err != nil {
+		t.Fatalf("expected Close() to return nil, got %v", err)
+	}
+
+	if err := second.Ping(context.Background()); err != nil {
+		t.Fatalf("expected second pool to stay up, got %v", err)
 	}
 }
 
-func TestNew(t *testing.T) {
-	srv := New()
-	if srv == nil {
-		t.Fatal("New() returned nil")
+func TestOpenInvalidConfig(t *testing.T) {
+	if _, err := Open(Config{}); err == nil {
+		t.Fatal("expected error for an empty config")
 	}
 }
 
 func TestHealth(t *testing.T) {
-	srv := New()
+	srv := openTestDB(t)
 
-	stats := srv.Health()
+	checks := health.NewRegistry(health.DefaultTimeout)
+	RegisterChecks(checks, srv)
 
-	if stats["status"] != "up" {
-		t.Fatalf("expected status to be up, got %s", stats["status"])
+	report := checks.Run(context.Background())
+
+	for _, name := range []string{"database", "database.pool"} {
+		if got := report.Checks[name].Status; got != health.StatusUp {
+			t.Fatalf("expected %s to be up, got %s: %s", name, got, report.Checks[name].Message)
+		}
 	}
 
-	if _, ok := stats["error"]; ok {
-		t.Fatalf("expected error not to be present")
+	// The container starts with an empty schema.
+	if got := report.Checks["database.migrations"].Status; got != health.StatusDegraded {
+		t.Fatalf("expected database.migrations to be degraded, got %s", got)
 	}
+}
+
+func TestHealthDown(t *testing.T) {
+	srv := openTestDB(t)
+	srv.Close()
 
-	if stats["message"] != "It's healthy" {
-		t.Fatalf("expected message to be 'It's healthy', got %s", stats["message"])
+	checks := health.NewRegistry(health.DefaultTimeout)
+	RegisterChecks(checks, srv)
+
+	if report := checks.Run(context.Background()); report.Status != health.StatusDown {
+		t.Fatalf("expected status to be down, got %s", report.Status)
 	}
 }
 
 func TestClose(t *testing.T) {
-	srv := New()
+	if testConfig.Host == "" {
+		t.Skip("postgres container not available")
+	}
+
+	srv, err := Open(testConfig)
+	if err != nil {
+		t.Fatalf("Open() returned error: %v", err)
+	}
 
 	if srv.Close() != nil {
 		t.Fatalf("expected Close() to return nil")
@@ -0,0 +1,142 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/BuildFrom/Golang-Stdlib/internal/sdk/errs"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Postgres error codes translated by Translate, besides the retryable ones.
+const (
+	codeNotNullViolation    = "23502"
+	codeForeignKeyViolation = "23503"
+	codeUniqueViolation     = "23505"
+	codeCheckViolation      = "23514"
+	codeQueryCanceled       = "57014"
+	codeAdminShutdown       = "57P01"
+	codeCrashShutdown       = "57P02"
+	codeCannotConnectNow    = "57P03"
+)
+
+// keyColumns matches the columns in the detail of a key violation, such as
+// "Key (title)=(groceries) already exists.".
+var keyColumns = regexp.MustCompile(`^Key \(([^)]+)\)=`)
+
+// Translate converts a database error into the errs.Error the client should
+// see, naming the offending columns, or the constraint when the columns are
+// unknown, as field errors:
+//
+//	23505         unique violation          AlreadyExists
+//	23502, 23514  not null, check violation InvalidArgument
+//	23503         foreign key violation     FailedPrecondition
+//	22xxx         data exception            InvalidArgument
+//	40001, 40P01  serialization, deadlock   Aborted
+//	57014         query canceled            Canceled
+//	connection failures                     Unavailable
+//
+// Any other error, including context errors and sql.ErrNoRows, is returned
+// unchanged. Translate is meant for the boundary to the client: retries and
+// IsRetryable need the original error.
+func Translate(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return translatePgError(pgErr, err)
+	}
+
+	// pgconn reports a context that was done before the query was sent as
+	// safe to retry, but it is not a connection failure.
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+
+	var connErr *pgconn.ConnectError
+	switch {
+	case errors.As(err, &connErr),
+		errors.Is(err, driver.ErrBadConn),
+		errors.Is(err, sql.ErrConnDone),
+		pgconn.SafeToRetry(err):
+		return errs.Newf(errs.Unavailable, "database unavailable")
+	}
+
+	return err
+}
+
+func translatePgError(pgErr *pgconn.PgError, err error) error {
+	var appErr *errs.Error
+
+	switch {
+	case pgErr.Code == codeUniqueViolation:
+		appErr = errs.Newf(errs.AlreadyExists, "%s", pgErr.Message)
+		appErr.Fields = keyFieldErrors(pgErr, "already exists")
+
+	case pgErr.Code == codeNotNullViolation:
+		appErr = errs.Newf(errs.InvalidArgument, "%s", pgErr.Message)
+		if pgErr.ColumnName != "" {
+			appErr.Fields = errs.FieldErrors{{Field: pgErr.ColumnName, Err: "is required"}}
+		}
+
+	case pgErr.Code == codeCheckViolation:
+		appErr = errs.Newf(errs.InvalidArgument, "%s", pgErr.Message)
+		if pgErr.ConstraintName != "" {
+			appErr.Fields = errs.FieldErrors{{Field: pgErr.ConstraintName, Err: "check failed"}}
+		}
+
+	case pgErr.Code == codeForeignKeyViolation:
+		appErr = errs.Newf(errs.FailedPrecondition, "%s", pgErr.Message)
+		appErr.Fields = keyFieldErrors(pgErr, "references a missing record")
+
+	case strings.HasPrefix(pgErr.Code, "22"):
+		// Postgres names no column for most data exceptions, such as a
+		// value too long for a VARCHAR (22001).
+		appErr = errs.Newf(errs.InvalidArgument, "%s", pgErr.Message)
+		if pgErr.ColumnName != "" {
+			appErr.Fields = errs.FieldErrors{{Field: pgErr.ColumnName, Err: pgErr.Message}}
+		}
+
+	case IsRetryable(err):
+		appErr = errs.Newf(errs.Aborted, "concurrent update, try again")
+
+	case pgErr.Code == codeQueryCanceled:
+		appErr = errs.Newf(errs.Canceled, "%s", pgErr.Message)
+
+	case strings.HasPrefix(pgErr.Code, "08"),
+		pgErr.Code == codeAdminShutdown,
+		pgErr.Code == codeCrashShutdown,
+		pgErr.Code == codeCannotConnectNow:
+		appErr = errs.Newf(errs.Unavailable, "database unavailable")
+
+	default:
+		return err
+	}
+
+	return appErr
+}
+
+// keyFieldErrors names the columns of a key violation, falling back to the
+// constraint name.
+func keyFieldErrors(pgErr *pgconn.PgError, msg string) errs.FieldErrors {
+	var fields errs.FieldErrors
+
+	if m := keyColumns.FindStringSubmatch(pgErr.Detail); m != nil {
+		for _, column := range strings.Split(m[1], ",") {
+			fields = append(fields, errs.FieldError{Field: strings.TrimSpace(column), Err: msg})
+		}
+		return fields
+	}
+
+	if pgErr.ConstraintName != "" {
+		fields = append(fields, errs.FieldError{Field: pgErr.ConstraintName, Err: msg})
+	}
+
+	return fields
+}
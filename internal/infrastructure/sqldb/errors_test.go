@@ -0,0 +1,122 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/BuildFrom/Golang-Stdlib/internal/sdk/errs"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestTranslate(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		code   errs.ErrCode
+		fields map[string]string
+	}{
+		{
+			name: "UniqueViolation",
+			err: fmt.Errorf("create: %w", &pgconn.PgError{
+				Code:           "23505",
+				Detail:         "Key (title)=(groceries) already exists.",
+				ConstraintName: "todos_title_key",
+			}),
+			code:   errs.AlreadyExists,
+			fields: map[string]string{"title": "already exists"},
+		},
+		{
+			name: "UniqueViolationComposite",
+			err: &pgconn.PgError{
+				Code:   "23505",
+				Detail: "Key (owner_id, title)=(1, groceries) already exists.",
+			},
+			code:   errs.AlreadyExists,
+			fields: map[string]string{"owner_id": "already exists", "title": "already exists"},
+		},
+		{
+			name:   "UniqueViolationConstraint",
+			err:    &pgconn.PgError{Code: "23505", ConstraintName: "todos_title_key"},
+			code:   errs.AlreadyExists,
+			fields: map[string]string{"todos_title_key": "already exists"},
+		},
+		{
+			name: "StringTooLong",
+			err:  &pgconn.PgError{Code: "22001", Message: "value too long for type character varying(50)"},
+			code: errs.InvalidArgument,
+		},
+		{
+			name:   "NotNull",
+			err:    &pgconn.PgError{Code: "23502", ColumnName: "title"},
+			code:   errs.InvalidArgument,
+			fields: map[string]string{"title": "is required"},
+		},
+		{
+			name:   "ForeignKey",
+			err:    &pgconn.PgError{Code: "23503", Detail: "Key (list_id)=(3) is not present in table \"lists\"."},
+			code:   errs.FailedPrecondition,
+			fields: map[string]string{"list_id": "references a missing record"},
+		},
+		{
+			name: "Serialization",
+			err:  &pgconn.PgError{Code: "40001"},
+			code: errs.Aborted,
+		},
+		{
+			name: "QueryCanceled",
+			err:  &pgconn.PgError{Code: "57014", Message: "canceling statement due to statement timeout"},
+			code: errs.Canceled,
+		},
+		{
+			name: "AdminShutdown",
+			err:  &pgconn.PgError{Code: "57P01"},
+			code: errs.Unavailable,
+		},
+		{
+			name: "ConnectionFailure",
+			err:  fmt.Errorf("query: %w", sql.ErrConnDone),
+			code: errs.Unavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var appErr *errs.Error
+			if !errors.As(Translate(tt.err), &appErr) {
+				t.Fatalf("expected an errs.Error, got %v", Translate(tt.err))
+			}
+
+			if appErr.Code != tt.code {
+				t.Errorf("expected code %s, got %s", tt.code, appErr.Code)
+			}
+
+			got := appErr.Fields.Fields()
+			if len(got) != len(tt.fields) {
+				t.Fatalf("expected fields %v, got %v", tt.fields, got)
+			}
+			for field, msg := range tt.fields {
+				if got[field] != msg {
+					t.Errorf("expected field %s to be %q, got %q", field, msg, got[field])
+				}
+			}
+		})
+	}
+}
+
+func TestTranslateUnchanged(t *testing.T) {
+	for _, err := range []error{
+		nil,
+		sql.ErrNoRows,
+		context.Canceled,
+		context.DeadlineExceeded,
+		&pgconn.PgError{Code: "42P01"},
+		errors.New("boom"),
+	} {
+		if got := Translate(err); got != err {
+			t.Errorf("expected %v unchanged, got %v", err, got)
+		}
+	}
+}
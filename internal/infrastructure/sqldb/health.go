@@ -0,0 +1,142 @@
+package sqldb
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/BuildFrom/Golang-Stdlib/internal/sdk/health"
+)
+
+// poolSaturation is the share of in-use connections above which the pool is
+// reported as degraded.
+const poolSaturation = 0.9
+
+// PoolStats is the connection pool part of the health report.
+type PoolStats struct {
+	MaxOpen           int    `json:"max_open"`
+	Open              int    `json:"open"`
+	InUse             int    `json:"in_use"`
+	Idle              int    `json:"idle"`
+	WaitCount         int64  `json:"wait_count"`
+	WaitDuration      string `json:"wait_duration"`
+	MaxIdleClosed     int64  `json:"max_idle_closed"`
+	MaxLifetimeClosed int64  `json:"max_lifetime_closed"`
+}
+
+// ReplicaInfo is the replica part of the health report.
+type ReplicaInfo struct {
+	Name string `json:"name"`
+}
+
+// MigrationInfo is the schema version part of the health report.
+type MigrationInfo struct {
+	Applied int `json:"applied"`
+	Latest  int `json:"latest"`
+}
+
+// RegisterChecks adds the database checks to the registry: connectivity,
+// pool saturation, schema version and one check per replica.
+func RegisterChecks(reg *health.Registry, db Service) {
+	reg.Register("database", PingCheck(db))
+	reg.Register("database.pool", PoolCheck(db))
+	reg.Register("database.migrations", MigrationCheck(db))
+
+	// Replicas are numbered in the order they were configured, as several
+	// may share a host.
+	if rs, ok := db.(interface{ replicaSet() []*replica }); ok {
+		for i, r := range rs.replicaSet() {
+			reg.Register("database.replica."+strconv.Itoa(i), replicaCheck(r))
+		}
+	}
+}
+
+// PingCheck reports whether the database answers.
+func PingCheck(db Service) health.CheckFunc {
+	return func(ctx context.Context) health.Result {
+		if err := db.Ping(ctx); err != nil {
+			return health.Down(fmt.Errorf("db down: %w", err))
+		}
+
+		return health.Up(nil)
+	}
+}
+
+// PoolCheck reports the pool statistics and is degraded when nearly every
+// connection is in use.
+func PoolCheck(db Service) health.CheckFunc {
+	return func(ctx context.Context) health.Result {
+		s := db.Stats()
+
+		stats := PoolStats{
+			MaxOpen:           s.MaxOpenConnections,
+			Open:              s.OpenConnections,
+			InUse:             s.InUse,
+			Idle:              s.Idle,
+			WaitCount:         s.WaitCount,
+			WaitDuration:      s.WaitDuration.String(),
+			MaxIdleClosed:     s.MaxIdleClosed,
+			MaxLifetimeClosed: s.MaxLifetimeClosed,
+		}
+
+		if s.MaxOpenConnections > 0 && float64(s.InUse) >= poolSaturation*float64(s.MaxOpenConnections) {
+			return health.Degraded("the connection pool is nearly exhausted, consider revising the connection pool settings", stats)
+		}
+
+		return health.Up(stats)
+	}
+}
+
+// MigrationCheck compares the applied schema version with the migrations
+// embedded in this build and is degraded when they differ.
+func MigrationCheck(db Service) health.CheckFunc {
+	return func(ctx context.Context) health.Result {
+		migrations, err := Migrations()
+		if err != nil {
+			return health.Down(err)
+		}
+
+		var info MigrationInfo
+		if n := len(migrations); n > 0 {
+			info.Latest = migrations[n-1].Version
+		}
+
+		// Replicas may lag behind a migration that just ran.
+		ctx = WithPrimary(ctx)
+
+		var exists bool
+		if err := db.QueryRowContext(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists); err != nil {
+			return health.Down(fmt.Errorf("schema version: %w", err))
+		}
+
+		if exists {
+			const q = `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`
+			if err := db.QueryRowContext(ctx, q).Scan(&info.Applied); err != nil {
+				return health.Down(fmt.Errorf("schema version: %w", err))
+			}
+		}
+
+		switch {
+		case info.Applied < info.Latest:
+			return health.Degraded("pending schema migrations", info)
+		case info.Applied > info.Latest:
+			return health.Degraded("schema is newer than this build", info)
+		}
+
+		return health.Up(info)
+	}
+}
+
+// replicaCheck pings a replica. A failed replica only degrades the service
+// since reads fall back to the primary.
+func replicaCheck(r *replica) health.CheckFunc {
+	return func(ctx context.Context) health.Result {
+		info := ReplicaInfo{Name: r.name}
+
+		if err := r.ping(ctx); err != nil {
+			return health.Degraded(fmt.Sprintf("replica down, reads use the primary: %v", err), info)
+		}
+
+		return health.Up(info)
+	}
+}
@@ -0,0 +1,343 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"expvar"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/BuildFrom/Golang-Stdlib/internal/sdk/trace"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// The operations a QueryEvent can describe.
+const (
+	OpExec     = "exec"
+	OpQuery    = "query"
+	OpQueryRow = "query_row"
+	OpTx       = "tx"
+)
+
+// redacted replaces every argument when Config.RedactArgs is set.
+const redacted = "[REDACTED]"
+
+// QueryEvent describes one statement or transaction run by the service.
+// Duration, Err and Class are set before After is called.
+type QueryEvent struct {
+	Op       string
+	Name     string
+	Query    string
+	Args     []any
+	Start    time.Time
+	Duration time.Duration
+	Err      error
+	Class    string
+}
+
+// Hook observes the statements run by the service. Before may return a
+// derived context, which is the one passed to After. The pgx extension
+// methods and statements run directly on a *sql.Tx are not observed.
+type Hook interface {
+	Before(ctx context.Context, ev *QueryEvent) context.Context
+	After(ctx context.Context, ev *QueryEvent)
+}
+
+// -----------------------------------------------------------------------------
+
+// hooks builds the chain for the configuration: metrics, slow query
+// logging, then the hooks from the config.
+func hooks(cfg Config) []Hook {
+	chain := []Hook{metricsHook{}}
+
+	if cfg.SlowQueryThreshold > 0 {
+		chain = append(chain, slowQueryHook{threshold: cfg.SlowQueryThreshold, log: cfg.logger()})
+	}
+
+	return append(chain, cfg.Hooks...)
+}
+
+// observe runs the Before hooks and returns the context to run the
+// statement with and a function to call with its error.
+func (s *service) observe(ctx context.Context, op string, query string, args []any) (context.Context, func(error)) {
+	if s.cfg.RedactArgs && len(args) > 0 {
+		masked := make([]any, len(args))
+		for i := range masked {
+			masked[i] = redacted
+		}
+		args = masked
+	}
+
+	ev := QueryEvent{
+		Op:    op,
+		Name:  queryName(op, query),
+		Query: query,
+		Args:  args,
+		Start: time.Now(),
+	}
+
+	for _, h := range s.hooks {
+		ctx = h.Before(ctx, &ev)
+	}
+
+	return ctx, func(err error) {
+		ev.Duration = time.Since(ev.Start)
+		ev.Err = err
+		ev.Class = ErrorClass(err)
+
+		for i := len(s.hooks) - 1; i >= 0; i-- {
+			s.hooks[i].After(ctx, &ev)
+		}
+	}
+}
+
+// comment appends the request and trace IDs carried by ctx to query, in the
+// sqlcommenter format, when Config.QueryComments is set. The hooks see the
+// query without it.
+func (s *service) comment(ctx context.Context, query string) string {
+	if !s.cfg.QueryComments {
+		return query
+	}
+
+	var tags []string
+	if id := trace.RequestID(ctx); id != "" {
+		tags = append(tags, "request_id='"+url.QueryEscape(id)+"'")
+	}
+	if p, ok := trace.ParentFromContext(ctx); ok {
+		tags = append(tags, "traceparent='"+url.QueryEscape(p.String())+"'")
+	}
+
+	if len(tags) == 0 {
+		return query
+	}
+
+	// The newline keeps a trailing line comment from swallowing the tags.
+	return query + "\n/*" + strings.Join(tags, ",") + "*/"
+}
+
+// queryName groups statements for metrics by their verb and table, such as
+// "select todos" or "update todos".
+func queryName(op string, query string) string {
+	if op == OpTx {
+		return OpTx
+	}
+
+	fields := strings.Fields(strings.ToLower(query))
+	if len(fields) == 0 {
+		return op
+	}
+
+	verb := fields[0]
+	for i, f := range fields[:len(fields)-1] {
+		switch {
+		case f == "from" && (verb == "select" || verb == "delete"),
+			f == "into" && verb == "insert",
+			f == "update" && verb == "update":
+			return verb + " " + strings.Trim(fields[i+1], `"(;`)
+		}
+	}
+
+	return verb
+}
+
+// -----------------------------------------------------------------------------
+
+// SQLSTATE classes reported by ErrorClass.
+var sqlStateClasses = map[string]string{
+	"08": "connection_exception",
+	"22": "data_exception",
+	"23": "integrity_constraint_violation",
+	"25": "invalid_transaction_state",
+	"40": "transaction_rollback",
+	"42": "syntax_error_or_access_rule_violation",
+	"53": "insufficient_resources",
+	"54": "program_limit_exceeded",
+	"57": "operator_intervention",
+	"58": "system_error",
+}
+
+// ErrorClass classifies err for metrics and logs: the SQLSTATE class name
+// for Postgres errors, "timeout" and "canceled" for context errors, and
+// "other" for anything else. No error, and sql.ErrNoRows, give "".
+func ErrorClass(err error) string {
+	if err == nil || errors.Is(err, sql.ErrNoRows) {
+		return ""
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && len(pgErr.Code) == 5 {
+		if class, ok := sqlStateClasses[pgErr.Code[:2]]; ok {
+			return class
+		}
+		return "sqlstate_" + pgErr.Code[:2]
+	}
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	}
+
+	var connErr *pgconn.ConnectError
+	if errors.As(err, &connErr) || pgconn.SafeToRetry(err) {
+		return "connection_exception"
+	}
+
+	return "other"
+}
+
+// -----------------------------------------------------------------------------
+
+// slowQueryHook logs every statement that takes longer than the threshold.
+type slowQueryHook struct {
+	threshold time.Duration
+	log       *slog.Logger
+}
+
+func (h slowQueryHook) Before(ctx context.Context, ev *QueryEvent) context.Context {
+	return ctx
+}
+
+func (h slowQueryHook) After(ctx context.Context, ev *QueryEvent) {
+	if ev.Duration < h.threshold {
+		return
+	}
+
+	attrs := []any{
+		"op", ev.Op,
+		"query", strings.Join(strings.Fields(ev.Query), " "),
+		"args", ev.Args,
+		"duration", ev.Duration,
+		"threshold", h.threshold,
+	}
+	if ev.Class != "" {
+		attrs = append(attrs, "error_class", ev.Class, "error", ev.Err)
+	}
+
+	h.log.WarnContext(ctx, "slow query", attrs...)
+}
+
+// -----------------------------------------------------------------------------
+
+// Query metrics are published once per process under the "sqldb" expvar,
+// shared by every service:
+//
+//	queries      count per query name
+//	errors       count per error class
+//	duration_ms  latency histogram per query name
+var (
+	metricsOnce       sync.Once
+	metricQueries     *expvar.Map
+	metricErrors      *expvar.Map
+	metricDurations   *expvar.Map
+	metricDurationsMu sync.Mutex
+)
+
+func publishMetrics() {
+	metricsOnce.Do(func() {
+		metricQueries = new(expvar.Map)
+		metricErrors = new(expvar.Map)
+		metricDurations = new(expvar.Map)
+
+		m := expvar.NewMap("sqldb")
+		m.Set("queries", metricQueries)
+		m.Set("errors", metricErrors)
+		m.Set("duration_ms", metricDurations)
+	})
+}
+
+// metricsHook counts statements and errors and records their latency.
+type metricsHook struct{}
+
+func (metricsHook) Before(ctx context.Context, ev *QueryEvent) context.Context {
+	return ctx
+}
+
+func (metricsHook) After(ctx context.Context, ev *QueryEvent) {
+	publishMetrics()
+
+	metricQueries.Add(ev.Name, 1)
+	if ev.Class != "" {
+		metricErrors.Add(ev.Class, 1)
+	}
+
+	durationHistogram(ev.Name).observe(ev.Duration)
+}
+
+// durationHistogram returns the latency histogram for the query name,
+// creating it on first use.
+func durationHistogram(name string) *histogram {
+	if h, ok := metricDurations.Get(name).(*histogram); ok {
+		return h
+	}
+
+	// Creation is serialized so concurrent first calls share one histogram.
+	metricDurationsMu.Lock()
+	defer metricDurationsMu.Unlock()
+
+	if h, ok := metricDurations.Get(name).(*histogram); ok {
+		return h
+	}
+
+	h := newHistogram()
+	metricDurations.Set(name, h)
+
+	return h
+}
+
+// histogramBuckets are the upper bounds, in milliseconds, of the latency
+// buckets. Slower observations only count towards the total.
+var histogramBuckets = []float64{1, 2.5, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000}
+
+// histogram is a fixed bucket latency histogram published through expvar.
+type histogram struct {
+	counts []atomic.Int64
+	count  atomic.Int64
+	sumUS  atomic.Int64
+}
+
+func newHistogram() *histogram {
+	return &histogram{counts: make([]atomic.Int64, len(histogramBuckets))}
+}
+
+func (h *histogram) observe(d time.Duration) {
+	ms := float64(d) / float64(time.Millisecond)
+
+	for i, le := range histogramBuckets {
+		if ms <= le {
+			h.counts[i].Add(1)
+			break
+		}
+	}
+
+	h.count.Add(1)
+	h.sumUS.Add(d.Microseconds())
+}
+
+// String implements expvar.Var. Bucket counts are cumulative, like a
+// Prometheus histogram.
+func (h *histogram) String() string {
+	var b strings.Builder
+
+	b.WriteString(`{"buckets":{`)
+
+	var cumulative int64
+	for i, le := range histogramBuckets {
+		cumulative += h.counts[i].Load()
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, `"%g":%d`, le, cumulative)
+	}
+
+	fmt.Fprintf(&b, `},"count":%d,"sum":%g}`, h.count.Load(), float64(h.sumUS.Load())/1000)
+
+	return b.String()
+}
@@ -0,0 +1,208 @@
+package sqldb
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BuildFrom/Golang-Stdlib/internal/sdk/trace"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// recordHook records the events it sees, tagged with its name.
+type recordHook struct {
+	name  string
+	calls *[]string
+	after []QueryEvent
+}
+
+func (h *recordHook) Before(ctx context.Context, ev *QueryEvent) context.Context {
+	*h.calls = append(*h.calls, "before "+h.name)
+	return ctx
+}
+
+func (h *recordHook) After(ctx context.Context, ev *QueryEvent) {
+	*h.calls = append(*h.calls, "after "+h.name)
+	h.after = append(h.after, *ev)
+}
+
+func TestErrorClass(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{"Nil", nil, ""},
+		{"NoRows", fmt.Errorf("get: %w", sql.ErrNoRows), ""},
+		{"UniqueViolation", &pgconn.PgError{Code: "23505"}, "integrity_constraint_violation"},
+		{"Serialization", fmt.Errorf("update: %w", &pgconn.PgError{Code: "40001"}), "transaction_rollback"},
+		{"UnknownClass", &pgconn.PgError{Code: "P0001"}, "sqlstate_P0"},
+		{"Timeout", context.DeadlineExceeded, "timeout"},
+		{"Canceled", context.Canceled, "canceled"},
+		{"Other", errors.New("boom"), "other"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ErrorClass(tt.err); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestQueryName(t *testing.T) {
+	tests := []struct {
+		op       string
+		query    string
+		expected string
+	}{
+		{OpQuery, "SELECT id, title FROM todos WHERE id = $1", "select todos"},
+		{OpExec, "\n\t\tINSERT INTO todos (title) VALUES ($1)", "insert todos"},
+		{OpExec, `UPDATE "todos" SET done = true`, "update todos"},
+		{OpExec, "DELETE FROM todos WHERE id = $1", "delete todos"},
+		{OpQueryRow, "SELECT 1", "select"},
+		{OpExec, "", OpExec},
+		{OpTx, "", OpTx},
+	}
+
+	for _, tt := range tests {
+		if got := queryName(tt.op, tt.query); got != tt.expected {
+			t.Errorf("expected %q for %q, got %q", tt.expected, tt.query, got)
+		}
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	h := newHistogram()
+	h.observe(500 * time.Microsecond)
+	h.observe(20 * time.Millisecond)
+	h.observe(10 * time.Second)
+
+	var got struct {
+		Buckets map[string]int64 `json:"buckets"`
+		Count   int64            `json:"count"`
+		Sum     float64          `json:"sum"`
+	}
+	if err := json.Unmarshal([]byte(h.String()), &got); err != nil {
+		t.Fatalf("expected valid JSON, got %v", err)
+	}
+
+	if got.Buckets["1"] != 1 || got.Buckets["25"] != 2 || got.Buckets["5000"] != 2 {
+		t.Errorf("expected cumulative buckets, got %v", got.Buckets)
+	}
+	if got.Count != 3 {
+		t.Errorf("expected 3 observations, got %d", got.Count)
+	}
+	if got.Sum != 10020.5 {
+		t.Errorf("expected a sum of 10020.5ms, got %g", got.Sum)
+	}
+}
+
+func TestMetricsHookConcurrentFirstUse(t *testing.T) {
+	const n = 50
+
+	name := fmt.Sprintf("select first_use_%d", time.Now().UnixNano())
+
+	start := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			metricsHook{}.After(context.Background(), &QueryEvent{Name: name, Duration: time.Millisecond})
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	if got := durationHistogram(name).count.Load(); got != n {
+		t.Errorf("expected %d observations, got %d", n, got)
+	}
+}
+
+func TestHooks(t *testing.T) {
+	db, err := sql.Open("pgx", "postgres://user@localhost/todos")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	var calls []string
+	first := &recordHook{name: "first", calls: &calls}
+	second := &recordHook{name: "second", calls: &calls}
+
+	var buf bytes.Buffer
+	cfg := Config{
+		SlowQueryThreshold: time.Nanosecond,
+		Logger:             slog.New(slog.NewTextHandler(&buf, nil)),
+		RedactArgs:         true,
+		Hooks:              []Hook{first, second},
+	}
+	s := service{db: db, cfg: cfg, hooks: hooks(cfg)}
+
+	// A canceled context fails before a connection is needed.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := s.ExecuteQueryContext(ctx, "UPDATE todos SET title = $1", "secret"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+
+	expected := []string{"before first", "before second", "after second", "after first"}
+	if strings.Join(calls, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected calls %v, got %v", expected, calls)
+	}
+
+	ev := first.after[0]
+	if ev.Op != OpExec || ev.Name != "update todos" || ev.Class != "canceled" {
+		t.Errorf("expected a canceled exec on todos, got %+v", ev)
+	}
+	if ev.Args[0] != redacted {
+		t.Errorf("expected redacted arguments, got %v", ev.Args)
+	}
+
+	log := buf.String()
+	if !strings.Contains(log, "slow query") || !strings.Contains(log, "error_class=canceled") {
+		t.Errorf("expected a slow query log line, got %q", log)
+	}
+	if strings.Contains(log, "secret") {
+		t.Errorf("expected arguments to be redacted from the log, got %q", log)
+	}
+}
+
+func TestComment(t *testing.T) {
+	p, err := trace.ParseParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := trace.WithParent(trace.WithRequestID(context.Background(), "abc"), p)
+
+	const query = "SELECT 1 -- one"
+
+	s := service{cfg: Config{QueryComments: true}}
+
+	expected := query + "\n/*request_id='abc',traceparent='00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01'*/"
+	if got := s.comment(ctx, query); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+
+	if got := s.comment(context.Background(), query); got != query {
+		t.Errorf("expected no comment without a request, got %q", got)
+	}
+
+	s.cfg.QueryComments = false
+	if got := s.comment(ctx, query); got != query {
+		t.Errorf("expected no comment when disabled, got %q", got)
+	}
+}
@@ -0,0 +1,318 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+//go:embed seeds/*.sql
+var seedFiles embed.FS
+
+// migrationLockID is the advisory lock key held while migrations run, so
+// two instances starting at once cannot migrate the same database.
+const migrationLockID = 4815162342
+
+// Migration is a single versioned schema change. Files are named
+// NNNN_name.up.sql and NNNN_name.down.sql.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationState reports whether a migration has been applied.
+type MigrationState struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+}
+
+// Migrations returns the embedded migrations ordered by version.
+func Migrations() ([]Migration, error) {
+	return loadMigrations(migrationFiles, "migrations")
+}
+
+func loadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("sqldb: read migrations: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+
+	for _, entry := range entries {
+		file := entry.Name()
+
+		base, direction, ok := strings.Cut(strings.TrimSuffix(file, ".sql"), ".")
+		if !ok || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("sqldb: migration %s: expected NNNN_name.up.sql or NNNN_name.down.sql", file)
+		}
+
+		num, name, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("sqldb: migration %s: missing name", file)
+		}
+
+		version, err := strconv.Atoi(num)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("sqldb: migration %s: invalid version %q", file, num)
+		}
+
+		data, err := fs.ReadFile(fsys, path.Join(dir, file))
+		if err != nil {
+			return nil, fmt.Errorf("sqldb: read migration %s: %w", file, err)
+		}
+
+		m, exists := byVersion[version]
+		if !exists {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		}
+		if m.Name != name {
+			return nil, fmt.Errorf("sqldb: migration version %d used by %q and %q", version, m.Name, name)
+		}
+
+		if direction == "up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("sqldb: migration %d_%s has no up file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// -----------------------------------------------------------------------------
+
+// MigrateUp applies every pending migration in order and returns the ones it
+// ran. Each migration runs in its own transaction holding the migration
+// lock, so a failure leaves the earlier ones applied.
+func MigrateUp(ctx context.Context, db Service) ([]Migration, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+
+	for _, m := range migrations {
+		var ran bool
+
+		err := db.Transaction(ctx, func(tx *sql.Tx) error {
+			versions, err := lockMigrations(ctx, tx)
+			if err != nil {
+				return err
+			}
+
+			// Another instance may have applied it while we waited on the
+			// lock.
+			if _, ok := versions[m.Version]; ok {
+				return nil
+			}
+
+			if _, err := tx.ExecContext(ctx, m.Up); err != nil {
+				return fmt.Errorf("sqldb: migration %d_%s up: %w", m.Version, m.Name, err)
+			}
+
+			const q = `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`
+			if _, err := tx.ExecContext(ctx, q, m.Version, m.Name); err != nil {
+				return fmt.Errorf("sqldb: record migration %d: %w", m.Version, err)
+			}
+
+			ran = true
+			return nil
+		})
+		if err != nil {
+			return applied, err
+		}
+
+		if ran {
+			applied = append(applied, m)
+		}
+	}
+
+	return applied, nil
+}
+
+// MigrateDown reverts the latest steps applied migrations, newest first.
+func MigrateDown(ctx context.Context, db Service, steps int) ([]Migration, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]Migration, len(migrations))
+	for _, m := range migrations {
+		byVersion[m.Version] = m
+	}
+
+	var reverted []Migration
+	for range steps {
+		var (
+			m   Migration
+			ran bool
+		)
+
+		err := db.Transaction(ctx, func(tx *sql.Tx) error {
+			versions, err := lockMigrations(ctx, tx)
+			if err != nil {
+				return err
+			}
+
+			latest := 0
+			for v := range versions {
+				latest = max(latest, v)
+			}
+			if latest == 0 {
+				return nil
+			}
+
+			var ok bool
+			if m, ok = byVersion[latest]; !ok {
+				return fmt.Errorf("sqldb: migration %d is applied but not known to this build", latest)
+			}
+			if m.Down == "" {
+				return fmt.Errorf("sqldb: migration %d_%s has no down file", m.Version, m.Name)
+			}
+
+			if _, err := tx.ExecContext(ctx, m.Down); err != nil {
+				return fmt.Errorf("sqldb: migration %d_%s down: %w", m.Version, m.Name, err)
+			}
+
+			const q = `DELETE FROM schema_migrations WHERE version = $1`
+			if _, err := tx.ExecContext(ctx, q, m.Version); err != nil {
+				return fmt.Errorf("sqldb: unrecord migration %d: %w", m.Version, err)
+			}
+
+			ran = true
+			return nil
+		})
+		if err != nil {
+			return reverted, err
+		}
+
+		if !ran {
+			break
+		}
+		reverted = append(reverted, m)
+	}
+
+	return reverted, nil
+}
+
+// MigrationStatus lists every known migration and when it was applied.
+func MigrationStatus(ctx context.Context, db Service) ([]MigrationState, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var versions map[int]time.Time
+	err = db.Transaction(ctx, func(tx *sql.Tx) error {
+		versions, err = lockMigrations(ctx, tx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	states := make([]MigrationState, len(migrations))
+	for i, m := range migrations {
+		states[i] = MigrationState{Version: m.Version, Name: m.Name}
+		if at, ok := versions[m.Version]; ok {
+			states[i].AppliedAt = &at
+		}
+	}
+
+	return states, nil
+}
+
+// Seed loads the embedded sample data. The seed files only insert into
+// empty tables, so running it twice is harmless.
+func Seed(ctx context.Context, db Service) error {
+	entries, err := fs.ReadDir(seedFiles, "seeds")
+	if err != nil {
+		return fmt.Errorf("sqldb: read seeds: %w", err)
+	}
+
+	return db.Transaction(ctx, func(tx *sql.Tx) error {
+		for _, entry := range entries {
+			data, err := fs.ReadFile(seedFiles, path.Join("seeds", entry.Name()))
+			if err != nil {
+				return fmt.Errorf("sqldb: read seed %s: %w", entry.Name(), err)
+			}
+
+			if _, err := tx.ExecContext(ctx, string(data)); err != nil {
+				return fmt.Errorf("sqldb: seed %s: %w", entry.Name(), err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// lockMigrations takes the migration lock for the rest of the transaction,
+// creates the bookkeeping table if needed and returns the applied versions.
+func lockMigrations(ctx context.Context, tx *sql.Tx) (map[int]time.Time, error) {
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, migrationLockID); err != nil {
+		return nil, fmt.Errorf("sqldb: migration lock: %w", err)
+	}
+
+	const create = `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`
+	if _, err := tx.ExecContext(ctx, create); err != nil {
+		return nil, fmt.Errorf("sqldb: create schema_migrations: %w", err)
+	}
+
+	rows, err := tx.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("sqldb: read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	versions := make(map[int]time.Time)
+	for rows.Next() {
+		var (
+			version int
+			at      time.Time
+		)
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, fmt.Errorf("sqldb: scan schema_migrations: %w", err)
+		}
+		versions[version] = at
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("sqldb: read schema_migrations: %w", err)
+	}
+
+	return versions, nil
+}
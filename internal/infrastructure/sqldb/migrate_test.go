@@ -0,0 +1,125 @@
+package sqldb
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+		err   bool
+	}{
+		{
+			name: "Ordered",
+			files: fstest.MapFS{
+				"m/0002_b.up.sql":   {Data: []byte("B")},
+				"m/0001_a.up.sql":   {Data: []byte("A")},
+				"m/0001_a.down.sql": {Data: []byte("-A")},
+			},
+		},
+		{
+			name:  "BadName",
+			files: fstest.MapFS{"m/create.sql": {Data: []byte("A")}},
+			err:   true,
+		},
+		{
+			name:  "MissingUp",
+			files: fstest.MapFS{"m/0001_a.down.sql": {Data: []byte("-A")}},
+			err:   true,
+		},
+		{
+			name: "DuplicateVersion",
+			files: fstest.MapFS{
+				"m/0001_a.up.sql": {Data: []byte("A")},
+				"m/0001_b.up.sql": {Data: []byte("B")},
+			},
+			err: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := loadMigrations(tt.files, "m")
+			if tt.err {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if len(migrations) != 2 || migrations[0].Version != 1 || migrations[1].Version != 2 {
+				t.Fatalf("expected versions 1 and 2 in order, got %+v", migrations)
+			}
+			if migrations[0].Down != "-A" {
+				t.Errorf("expected down script to be loaded, got %q", migrations[0].Down)
+			}
+		})
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := Migrations()
+	if err != nil {
+		t.Fatalf("expected embedded migrations to load, got %v", err)
+	}
+
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			t.Errorf("expected version %d, got %d", i+1, m.Version)
+		}
+		if m.Down == "" {
+			t.Errorf("expected migration %d to have a down file", m.Version)
+		}
+	}
+}
+
+func TestMigrateUpAndDown(t *testing.T) {
+	srv := openTestDB(t)
+	ctx := context.Background()
+
+	all, err := Migrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err := MigrateUp(ctx, srv)
+	if err != nil {
+		t.Fatalf("expected migrations to apply, got %v", err)
+	}
+	if len(applied) != len(all) {
+		t.Fatalf("expected %d migrations applied, got %d", len(all), len(applied))
+	}
+
+	if err := Seed(ctx, srv); err != nil {
+		t.Fatalf("expected seed to succeed, got %v", err)
+	}
+
+	again, err := MigrateUp(ctx, srv)
+	if err != nil || len(again) != 0 {
+		t.Fatalf("expected second run to be a no-op, got %d applied, err %v", len(again), err)
+	}
+
+	reverted, err := MigrateDown(ctx, srv, len(all)+1)
+	if err != nil {
+		t.Fatalf("expected migrations to revert, got %v", err)
+	}
+	if len(reverted) != len(all) {
+		t.Fatalf("expected %d migrations reverted, got %d", len(all), len(reverted))
+	}
+
+	states, err := MigrationStatus(ctx, srv)
+	if err != nil {
+		t.Fatalf("expected status, got %v", err)
+	}
+	for _, s := range states {
+		if s.AppliedAt != nil {
+			t.Errorf("expected migration %d to be pending", s.Version)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS todos (
+    id INTEGER PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+    title VARCHAR(50) NOT NULL,
+    status VARCHAR(15) NOT NULL DEFAULT 'INCOMPLETE',
+    expires_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_todos_created ON todos(created_at);
@@ -0,0 +1,2 @@
+ALTER TABLE todos
+    ADD COLUMN IF NOT EXISTS archive BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_todos_deleted;
+
+ALTER TABLE todos
+    DROP COLUMN IF EXISTS deleted_at;
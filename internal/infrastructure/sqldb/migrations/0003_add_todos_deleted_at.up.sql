@@ -0,0 +1,4 @@
+ALTER TABLE todos
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_todos_deleted ON todos(deleted_at) WHERE deleted_at IS NOT NULL;
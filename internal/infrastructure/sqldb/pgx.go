@@ -0,0 +1,152 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// The backends Config.Driver can select.
+const (
+	// DriverStdlib runs on database/sql with its own connection pool.
+	DriverStdlib = "stdlib"
+
+	// DriverPgxPool runs on a native pgxpool.Pool. The Service methods go
+	// through a database/sql adapter over the same pool, and the pgx
+	// extension interfaces below become available.
+	DriverPgxPool = "pgxpool"
+)
+
+// Copier is implemented by services that can bulk load rows with COPY.
+type Copier interface {
+	CopyFrom(ctx context.Context, table pgx.Identifier, columns []string, rows pgx.CopyFromSource) (int64, error)
+}
+
+// Batcher is implemented by services that can send several queries in one
+// round trip.
+type Batcher interface {
+	SendBatch(ctx context.Context, batch *pgx.Batch) pgx.BatchResults
+}
+
+// Listener is implemented by services that can receive NOTIFY messages.
+type Listener interface {
+	// Listen holds a connection listening on channel and calls fn for
+	// every notification until ctx is done or the connection fails.
+	Listen(ctx context.Context, channel string, fn func(*pgconn.Notification)) error
+}
+
+// PoolProvider is implemented by services backed by a pgxpool.Pool, for the
+// native pgx API not covered by the other interfaces.
+type PoolProvider interface {
+	Pool() *pgxpool.Pool
+}
+
+// -----------------------------------------------------------------------------
+
+// pgxService is the Service backed by pgxpool. The pgx specific methods use
+// the primary pool directly and do not join a transaction carried by the
+// context.
+type pgxService struct {
+	*service
+	pool *pgxpool.Pool
+}
+
+// openPgxPool opens a native pool and a database/sql handle over it.
+func openPgxPool(cfg Config) (*sql.DB, *pgxpool.Pool, error) {
+	dsn, err := cfg.DSN()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	poolCfg, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, nil, fmt.Errorf("sqldb: parse pgxpool config: %w", err)
+	}
+
+	if cfg.MaxOpenConns > 0 {
+		poolCfg.MaxConns = int32(cfg.MaxOpenConns)
+	}
+	if cfg.ConnMaxLifetime > 0 {
+		poolCfg.MaxConnLifetime = cfg.ConnMaxLifetime
+	}
+	if cfg.ConnMaxIdleTime > 0 {
+		poolCfg.MaxConnIdleTime = cfg.ConnMaxIdleTime
+	}
+
+	// NewWithConfig does not connect, matching sql.Open.
+	pool, err := pgxpool.NewWithConfig(context.Background(), poolCfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("sqldb: open pgxpool: %w", err)
+	}
+
+	// Idle connections are kept by pgxpool until MaxConnIdleTime, so
+	// MaxIdleConns has no equivalent. See stdlib.OpenDBFromPool.
+	db := stdlib.OpenDBFromPool(pool)
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+
+	return db, pool, nil
+}
+
+// Pool returns the primary pool.
+func (s *pgxService) Pool() *pgxpool.Pool {
+	return s.pool
+}
+
+// CopyFrom bulk loads rows into table on the primary.
+func (s *pgxService) CopyFrom(ctx context.Context, table pgx.Identifier, columns []string, rows pgx.CopyFromSource) (int64, error) {
+	return s.pool.CopyFrom(ctx, table, columns, rows)
+}
+
+// SendBatch sends the queued queries to the primary in one round trip.
+func (s *pgxService) SendBatch(ctx context.Context, batch *pgx.Batch) pgx.BatchResults {
+	return s.pool.SendBatch(ctx, batch)
+}
+
+// Listen holds a connection listening on channel and calls fn for every
+// notification until ctx is done or the connection fails.
+func (s *pgxService) Listen(ctx context.Context, channel string, fn func(*pgconn.Notification)) error {
+	pooled, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("sqldb: listen: %w", err)
+	}
+
+	// The session keeps listening on channel, so it is taken out of the
+	// pool and closed rather than reused.
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+		return fmt.Errorf("sqldb: listen %s: %w", channel, err)
+	}
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("sqldb: listen %s: %w", channel, err)
+		}
+
+		fn(n)
+	}
+}
+
+// Close closes the database/sql handles before the pools under them.
+func (s *pgxService) Close() error {
+	err := s.service.Close()
+
+	for _, r := range s.replicas {
+		if r.pool != nil {
+			r.pool.Close()
+		}
+	}
+	s.pool.Close()
+
+	return err
+}
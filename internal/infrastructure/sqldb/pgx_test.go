@@ -0,0 +1,136 @@
+package sqldb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestOpenDrivers(t *testing.T) {
+	cfg := Config{
+		Host:     "localhost",
+		Port:     "5432",
+		User:     "user",
+		Database: "todos",
+	}
+
+	tests := []struct {
+		driver string
+		native bool
+	}{
+		{"", false},
+		{DriverStdlib, false},
+		{DriverPgxPool, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.driver, func(t *testing.T) {
+			cfg := cfg
+			cfg.Driver = tt.driver
+
+			// Neither backend connects until it is used.
+			srv, err := Open(cfg)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			defer srv.Close()
+
+			_, copier := srv.(Copier)
+			_, batcher := srv.(Batcher)
+			_, listener := srv.(Listener)
+			_, provider := srv.(PoolProvider)
+
+			if copier != tt.native || batcher != tt.native || listener != tt.native || provider != tt.native {
+				t.Errorf("expected pgx extensions to be %v, got copier=%v batcher=%v listener=%v pool=%v", tt.native, copier, batcher, listener, provider)
+			}
+		})
+	}
+
+	cfg.Driver = "mysql"
+	if _, err := Open(cfg); err == nil {
+		t.Error("expected error for an unknown driver")
+	}
+}
+
+func TestPgxExtensions(t *testing.T) {
+	if testConfig.Host == "" {
+		t.Skip("postgres container not available")
+	}
+
+	cfg := testConfig
+	cfg.Driver = DriverPgxPool
+
+	srv, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Open() returned error: %v", err)
+	}
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := srv.ExecuteQueryContext(ctx, `CREATE TABLE copy_test (id INT, name TEXT)`); err != nil {
+		t.Fatal(err)
+	}
+	defer srv.ExecuteQueryContext(context.Background(), `DROP TABLE copy_test`)
+
+	rows := [][]any{{1, "a"}, {2, "b"}, {3, "c"}}
+	n, err := srv.(Copier).CopyFrom(ctx, pgx.Identifier{"copy_test"}, []string{"id", "name"}, pgx.CopyFromRows(rows))
+	if err != nil || n != 3 {
+		t.Fatalf("expected 3 rows copied, got %d, err %v", n, err)
+	}
+
+	batch := &pgx.Batch{}
+	batch.Queue(`SELECT count(*) FROM copy_test`)
+	batch.Queue(`SELECT max(id) FROM copy_test`)
+	results := srv.(Batcher).SendBatch(ctx, batch)
+
+	var count, maxID int
+	if err := results.QueryRow().Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if err := results.QueryRow().Scan(&maxID); err != nil {
+		t.Fatal(err)
+	}
+	results.Close()
+
+	if count != 3 || maxID != 3 {
+		t.Errorf("expected count 3 and max 3, got %d and %d", count, maxID)
+	}
+
+	received := make(chan string, 1)
+	listenCtx, stop := context.WithCancel(ctx)
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.(Listener).Listen(listenCtx, "todos", func(n *pgconn.Notification) {
+			select {
+			case received <- n.Payload:
+			default:
+			}
+		})
+	}()
+
+	// Notify until the listener has subscribed and picked one up.
+	for payload := ""; payload == ""; {
+		if _, err := srv.ExecuteQueryContext(ctx, `SELECT pg_notify('todos', 'hello')`); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case payload = <-received:
+			if payload != "hello" {
+				t.Errorf("expected payload hello, got %q", payload)
+			}
+		case <-time.After(100 * time.Millisecond):
+		case <-ctx.Done():
+			t.Fatal("no notification received")
+		}
+	}
+
+	stop()
+	if err := <-done; err != nil {
+		t.Errorf("expected Listen to stop cleanly, got %v", err)
+	}
+}
@@ -0,0 +1,140 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type primaryKey struct{}
+
+// WithPrimary returns a context that routes reads to the primary. Use it for
+// reads that must see a write made moments before, since replicas may lag.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// usePrimary reports whether the context asks for reads on the primary.
+func usePrimary(ctx context.Context) bool {
+	v, _ := ctx.Value(primaryKey{}).(bool)
+	return v
+}
+
+// -----------------------------------------------------------------------------
+
+// replica is a read-only pool. It is taken out of rotation while its pings
+// fail.
+type replica struct {
+	name    string
+	db      *sql.DB
+	pool    *pgxpool.Pool
+	healthy atomic.Bool
+}
+
+func openReplicas(cfg Config) ([]*replica, error) {
+	configs, err := cfg.replicaConfigs()
+	if err != nil {
+		return nil, err
+	}
+
+	replicas := make([]*replica, 0, len(configs))
+
+	for _, rc := range configs {
+		db, pool, err := openDB(rc)
+		if err != nil {
+			closeReplicas(replicas)
+			for _, r := range replicas {
+				if r.pool != nil {
+					r.pool.Close()
+				}
+			}
+			return nil, err
+		}
+
+		// The database is part of the name, as replicas may share a host.
+		name := rc.URL
+		if u, err := url.Parse(rc.URL); err == nil {
+			name = u.Host + u.Path
+		}
+
+		r := replica{name: name, db: db, pool: pool}
+		r.healthy.Store(true)
+		replicas = append(replicas, &r)
+	}
+
+	return replicas, nil
+}
+
+func closeReplicas(replicas []*replica) error {
+	var firstErr error
+	for _, r := range replicas {
+		if err := r.db.Close(); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("sqldb: close replica %s: %w", r.name, err)
+		}
+	}
+	return firstErr
+}
+
+// ping checks the replica and updates whether it takes reads.
+func (r *replica) ping(ctx context.Context) error {
+	err := r.db.PingContext(ctx)
+	r.healthy.Store(err == nil)
+	return err
+}
+
+// -----------------------------------------------------------------------------
+
+// primary returns the pool writes use.
+func (s *service) primary(ctx context.Context) *sql.DB {
+	return s.db
+}
+
+// replicaSet returns the replicas, for the health checks.
+func (s *service) replicaSet() []*replica {
+	return s.replicas
+}
+
+// reader returns the pool a read should use: the next healthy replica in
+// turn, or the primary when the context asks for it or no replica is up.
+func (s *service) reader(ctx context.Context) *sql.DB {
+	if len(s.replicas) == 0 || usePrimary(ctx) {
+		return s.db
+	}
+
+	start := s.next.Add(1)
+	for i := range len(s.replicas) {
+		r := s.replicas[(start+uint64(i))%uint64(len(s.replicas))]
+		if r.healthy.Load() {
+			return r.db
+		}
+	}
+
+	return s.db
+}
+
+// monitorReplicas pings every replica on the interval until ctx is done, so
+// a failed replica leaves and a recovered one rejoins the rotation.
+func (s *service) monitorReplicas(ctx context.Context, interval time.Duration) {
+	defer close(s.monitorDone)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, r := range s.replicas {
+				pingCtx, cancel := context.WithTimeout(ctx, interval)
+				r.ping(pingCtx)
+				cancel()
+			}
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/BuildFrom/Golang-Stdlib/internal/sdk/health"
+)
+
+func TestReader(t *testing.T) {
+	open := func(name string) *sql.DB {
+		db, err := sql.Open("pgx", "postgres://user@"+name+"/todos")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+		return db
+	}
+
+	s := service{db: open("primary")}
+	for _, name := range []string{"r1", "r2", "r3"} {
+		r := replica{name: name, db: open(name)}
+		r.healthy.Store(true)
+		s.replicas = append(s.replicas, &r)
+	}
+
+	ctx := context.Background()
+
+	seen := make(map[*sql.DB]int)
+	for range 6 {
+		seen[s.reader(ctx)]++
+	}
+	for _, r := range s.replicas {
+		if seen[r.db] != 2 {
+			t.Errorf("expected replica %s to serve 2 reads, got %d", r.name, seen[r.db])
+		}
+	}
+
+	if s.reader(WithPrimary(ctx)) != s.db {
+		t.Error("expected WithPrimary to read from the primary")
+	}
+
+	s.replicas[0].healthy.Store(false)
+	s.replicas[1].healthy.Store(false)
+	for range 3 {
+		if got := s.reader(ctx); got != s.replicas[2].db {
+			t.Fatal("expected reads to skip unhealthy replicas")
+		}
+	}
+
+	s.replicas[2].healthy.Store(false)
+	if s.reader(ctx) != s.db {
+		t.Error("expected reads to fall back to the primary")
+	}
+}
+
+func TestReplicaChecks(t *testing.T) {
+	open := func(dsn string) *sql.DB {
+		db, err := sql.Open("pgx", dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+		return db
+	}
+
+	// Two replicas on the same host used to register the same check.
+	s := service{db: open("postgres://user@localhost:1/todos")}
+	for _, name := range []string{"localhost:1/todos", "localhost:1/reports"} {
+		r := replica{name: name, db: open("postgres://user@" + name)}
+		r.healthy.Store(true)
+		s.replicas = append(s.replicas, &r)
+	}
+
+	checks := health.NewRegistry(health.DefaultTimeout)
+	RegisterChecks(checks, &s)
+
+	report := checks.Run(context.Background())
+
+	for i, r := range s.replicas {
+		name := fmt.Sprintf("database.replica.%d", i)
+
+		result, ok := report.Checks[name]
+		if !ok {
+			t.Fatalf("expected check %s, got %v", name, report.Checks)
+		}
+		if info, _ := result.Details.(ReplicaInfo); info.Name != r.name {
+			t.Errorf("expected %s to report replica %s, got %v", name, r.name, result.Details)
+		}
+	}
+}
+
+func TestReplicaConfigs(t *testing.T) {
+	cfg := Config{
+		Host:        "primary",
+		Port:        "5432",
+		User:        "user",
+		Password:    "secret",
+		Database:    "todos",
+		SSLMode:     "require",
+		ReplicaURLs: []string{"postgres://replica1:5432", "postgres://other@replica2/reports"},
+	}
+
+	configs, err := cfg.replicaConfigs()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(configs) != 2 {
+		t.Fatalf("expected 2 replica configs, got %d", len(configs))
+	}
+
+	first, _ := url.Parse(configs[0].URL)
+	if pwd, _ := first.User.Password(); first.User.Username() != "user" || pwd != "secret" || first.Path != "/todos" {
+		t.Errorf("expected replica to inherit credentials and database, got %s", first.Redacted())
+	}
+
+	second, _ := url.Parse(configs[1].URL)
+	if second.User.Username() != "other" || second.Path != "/reports" {
+		t.Errorf("expected replica to keep its own credentials and database, got %s", second.Redacted())
+	}
+
+	dsn, err := configs[0].DSN()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	u, _ := url.Parse(dsn)
+	if u.Query().Get("sslmode") != "require" || u.Host != "replica1:5432" {
+		t.Errorf("expected replica DSN to share options with the primary, got %s", u.Redacted())
+	}
+}
@@ -0,0 +1,8 @@
+INSERT INTO todos (title, status, expires_at)
+SELECT title, status, expires_at::timestamp
+FROM (VALUES
+    ('Learn SQL', 'INCOMPLETE', '2024-11-18 15:45:00'),
+    ('Build Todo App', 'INCOMPLETE', '2024-12-01 12:00:00'),
+    ('Review PostgreSQL Guide', 'COMPLETE', '2024-11-20 09:30:00')
+) AS seed(title, status, expires_at)
+WHERE NOT EXISTS (SELECT 1 FROM todos);
@@ -4,20 +4,20 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"log"
-	"os"
-	"strconv"
-	"time"
+	"sync/atomic"
 
+	"github.com/jackc/pgx/v5/pgxpool"
 	_ "github.com/jackc/pgx/v5/stdlib"
 	_ "github.com/joho/godotenv/autoload"
 )
 
 // Service represents a service that interacts with a database.
 type Service interface {
-	// Health returns a map of health status information.
-	// The keys and values in the map are service-specific.
-	Health() map[string]string
+	// Ping verifies a connection to the database is still alive.
+	Ping(ctx context.Context) error
+
+	// Stats returns the connection pool statistics.
+	Stats() sql.DBStats
 
 	// Close terminates the database connection.
 	// It returns an error if the connection cannot be closed.
@@ -28,148 +28,214 @@ type Service interface {
 	ExecuteQuery(query string, args ...interface{}) (sql.Result, error)
 
 	// QueryRow executes the query with the given arguments and returns a single row.
-	// It returns the result of the query execution.
+	// It returns the result of the query execution. Reads may be served by
+	// a replica, see WithPrimary.
 	QueryRow(query string, args ...interface{}) *sql.Row
 
 	// Query executes the query with the given arguments and returns the result set.
-	// It returns the result of the query execution.
+	// It returns the result of the query execution. Reads may be served by
+	// a replica, see WithPrimary.
 	Query(query string, args ...interface{}) (*sql.Rows, error)
 
+	// ExecuteQueryContext is like ExecuteQuery but the query is canceled
+	// when the context is done.
+	ExecuteQueryContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+
+	// QueryRowContext is like QueryRow but the query is canceled when the
+	// context is done.
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+
+	// QueryContext is like Query but the query is canceled when the context
+	// is done.
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+
 	// Transaction executes the function within a transaction.
 	// If the function returns an error, the transaction is rolled back.
 	// If the function returns nil, the transaction is committed.
 	Transaction(ctx context.Context, fn func(tx *sql.Tx) error) error
+
+	// InTx runs fn in a transaction carried by the context passed to it.
+	// The context methods above join that transaction, and nested calls
+	// use savepoints. Serialization failures and deadlocks are retried.
+	InTx(ctx context.Context, opts TxOptions, fn func(ctx context.Context) error) error
 }
 
 type service struct {
-	db *sql.DB
+	db    *sql.DB
+	cfg   Config
+	hooks []Hook
+
+	replicas    []*replica
+	next        atomic.Uint64
+	stopMonitor context.CancelFunc
+	monitorDone chan struct{}
 }
 
-var (
-	database   = os.Getenv("BLUEPRINT_DB_DATABASE")
-	password   = os.Getenv("BLUEPRINT_DB_PASSWORD")
-	username   = os.Getenv("BLUEPRINT_DB_USERNAME")
-	port       = os.Getenv("BLUEPRINT_DB_PORT")
-	host       = os.Getenv("BLUEPRINT_DB_HOST")
-	schema     = os.Getenv("BLUEPRINT_DB_SCHEMA")
-	dbInstance *service
-)
-
-func New() Service {
-	// Reuse Connection
-	if dbInstance != nil {
-		return dbInstance
+// Open opens a connection pool using the given configuration. The
+// configuration is validated first and the pool settings are applied to the
+// returned service. Every call returns a new, independent pool, so callers
+// own it and must Close it when done.
+//
+// When replicas are configured, Query and QueryRow are spread over the
+// healthy ones while writes and transactions always use the primary.
+// Statements that write through Query or QueryRow, such as INSERT ...
+// RETURNING, must run with a WithPrimary context.
+//
+// Every statement and transaction run through the service passes the hook
+// chain: query metrics, slow query logging when cfg.SlowQueryThreshold is
+// set, then cfg.Hooks.
+func Open(cfg Config) (Service, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
 	}
-	connStr := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable&search_path=%s", username, password, host, port, database, schema)
-	db, err := sql.Open("pgx", connStr)
+
+	db, pool, err := openDB(cfg)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
-	dbInstance = &service{
-		db: db,
+
+	replicas, err := openReplicas(cfg)
+	if err != nil {
+		db.Close()
+		if pool != nil {
+			pool.Close()
+		}
+		return nil, err
 	}
-	return dbInstance
-}
 
-// Health checks the health of the database connection by pinging the database.
-// It returns a map with keys indicating various health statistics.
-func (s *service) Health() map[string]string {
-	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
-	defer cancel()
+	s := service{
+		db:       db,
+		cfg:      cfg,
+		hooks:    hooks(cfg),
+		replicas: replicas,
+	}
 
-	stats := make(map[string]string)
+	if len(replicas) > 0 {
+		interval := cfg.ReplicaCheckInterval
+		if interval <= 0 {
+			interval = DefaultReplicaCheckInterval
+		}
 
-	// Ping the database
-	err := s.db.PingContext(ctx)
-	if err != nil {
-		stats["status"] = "down"
-		stats["error"] = fmt.Sprintf("db down: %v", err)
-		log.Fatalf("db down: %v", err) // Log the error and terminate the program
-		return stats
+		ctx, cancel := context.WithCancel(context.Background())
+		s.stopMonitor = cancel
+		s.monitorDone = make(chan struct{})
+		go s.monitorReplicas(ctx, interval)
 	}
 
-	// Database is up, add more statistics
-	stats["status"] = "up"
-	stats["message"] = "It's healthy"
-
-	// Get database stats (like open connections, in use, idle, etc.)
-	dbStats := s.db.Stats()
-	stats["open_connections"] = strconv.Itoa(dbStats.OpenConnections)
-	stats["in_use"] = strconv.Itoa(dbStats.InUse)
-	stats["idle"] = strconv.Itoa(dbStats.Idle)
-	stats["wait_count"] = strconv.FormatInt(dbStats.WaitCount, 10)
-	stats["wait_duration"] = dbStats.WaitDuration.String()
-	stats["max_idle_closed"] = strconv.FormatInt(dbStats.MaxIdleClosed, 10)
-	stats["max_lifetime_closed"] = strconv.FormatInt(dbStats.MaxLifetimeClosed, 10)
-
-	// Evaluate stats to provide a health message
-	if dbStats.OpenConnections > 40 { // Assuming 50 is the max for this example
-		stats["message"] = "The database is experiencing heavy load."
+	if pool != nil {
+		return &pgxService{service: &s, pool: pool}, nil
 	}
 
-	if dbStats.WaitCount > 1000 {
-		stats["message"] = "The database has a high number of wait events, indicating potential bottlenecks."
+	return &s, nil
+}
+
+// openDB opens a single pool with the backend selected by cfg.Driver. The
+// native pool is only returned for DriverPgxPool.
+func openDB(cfg Config) (*sql.DB, *pgxpool.Pool, error) {
+	if cfg.Driver == DriverPgxPool {
+		return openPgxPool(cfg)
 	}
 
-	if dbStats.MaxIdleClosed > int64(dbStats.OpenConnections)/2 {
-		stats["message"] = "Many idle connections are being closed, consider revising the connection pool settings."
+	dsn, err := cfg.DSN()
+	if err != nil {
+		return nil, nil, err
 	}
 
-	if dbStats.MaxLifetimeClosed > int64(dbStats.OpenConnections)/2 {
-		stats["message"] = "Many connections are being closed due to max lifetime, consider increasing max lifetime or revising the connection usage pattern."
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return nil, nil, fmt.Errorf("sqldb: open: %w", err)
 	}
 
-	return stats
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+	return db, nil, nil
+}
+
+// Ping verifies a connection to the database is still alive.
+func (s *service) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// Stats returns the connection pool statistics.
+func (s *service) Stats() sql.DBStats {
+	return s.db.Stats()
 }
 
 // Close closes the database connection.
-// It logs a message indicating the disconnection from the specific database.
+// It logs a message indicating the disconnection from the specific database
+// to the configured logger.
 // If the connection is successfully closed, it returns nil.
 // If an error occurs while closing the connection, it returns the error.
 func (s *service) Close() error {
-	log.Printf("Disconnected from database: %s", database)
-	return s.db.Close()
+	if s.stopMonitor != nil {
+		s.stopMonitor()
+		<-s.monitorDone
+	}
+
+	replicaErr := closeReplicas(s.replicas)
+
+	s.cfg.logger().Info("Disconnected from database", "database", s.cfg.Database)
+	if err := s.db.Close(); err != nil {
+		return err
+	}
+
+	return replicaErr
 }
 
 // ExecuteQuery executes the query with the given arguments.
 // It returns the result of the query execution.
 func (s *service) ExecuteQuery(query string, args ...interface{}) (sql.Result, error) {
-	return s.db.Exec(query, args...)
+	return s.ExecuteQueryContext(context.Background(), query, args...)
 }
 
 // QueryRow executes the query with the given arguments and returns a single row.
 // It returns the result of the query execution.
 func (s *service) QueryRow(query string, args ...interface{}) *sql.Row {
-	return s.db.QueryRow(query, args...)
+	return s.QueryRowContext(context.Background(), query, args...)
 }
 
 // Query executes the query with the given arguments and returns the result set.
 // It returns the result of the query execution.
 func (s *service) Query(query string, args ...interface{}) (*sql.Rows, error) {
-	return s.db.Query(query, args...)
+	return s.QueryContext(context.Background(), query, args...)
 }
 
-// Transaction executes the function within a transaction.
-// If the function returns an error, the transaction is rolled back.
-// If the function returns nil, the transaction is committed.
-func (s *service) Transaction(ctx context.Context, fn func(tx *sql.Tx) error) error {
-	tx, err := s.db.BeginTx(ctx, nil)
-	if err != nil {
-		return err
-	}
+// ExecuteQueryContext executes the query with the given arguments.
+// The query is canceled when the context is done. It runs in the
+// transaction carried by ctx, if any.
+func (s *service) ExecuteQueryContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, done := s.observe(ctx, OpExec, query, args)
 
-	defer func() {
-		if p := recover(); p != nil {
-			tx.Rollback()
-			panic(p)
-		}
-	}()
+	res, err := s.conn(ctx, s.primary).ExecContext(ctx, s.comment(ctx, query), args...)
+	done(err)
 
-	err = fn(tx)
-	if err != nil {
-		tx.Rollback()
-		return err
-	}
+	return res, err
+}
+
+// QueryRowContext executes the query with the given arguments and returns a single row.
+// The query is canceled when the context is done. It runs in the
+// transaction carried by ctx, if any.
+func (s *service) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, done := s.observe(ctx, OpQueryRow, query, args)
+
+	row := s.conn(ctx, s.reader).QueryRowContext(ctx, s.comment(ctx, query), args...)
+	done(row.Err())
+
+	return row
+}
+
+// QueryContext executes the query with the given arguments and returns the result set.
+// The query is canceled when the context is done. It runs in the
+// transaction carried by ctx, if any. The hooks time the query up to the
+// first row, not the iteration over the result set.
+func (s *service) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, done := s.observe(ctx, OpQuery, query, args)
+
+	rows, err := s.conn(ctx, s.reader).QueryContext(ctx, s.comment(ctx, query), args...)
+	done(err)
 
-	return tx.Commit()
+	return rows, err
 }
@@ -0,0 +1,116 @@
+// Package sqldbtest starts a throwaway Postgres container for the tests that
+// need a real database.
+package sqldbtest
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// SkipEnv is the environment variable that lets the database tests be
+// skipped when no container can be started, such as on machines without
+// Docker. Without it they fail instead, so a green run always means the
+// database was exercised.
+const SkipEnv = "SQLDB_SKIP_CONTAINER"
+
+// Container is a running Postgres container and the credentials to reach it.
+type Container struct {
+	Host     string
+	Port     string
+	Database string
+	User     string
+	Password string
+
+	terminate func(context.Context) error
+}
+
+// Start starts a Postgres container and waits until it accepts connections.
+func Start() (c *Container, err error) {
+	// testcontainers panics when it cannot find a Docker host at all.
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("%v", p)
+		}
+	}()
+
+	c = &Container{
+		Database: "database",
+		User:     "user",
+		Password: "password",
+	}
+
+	ctx := context.Background()
+
+	dbContainer, err := postgres.Run(
+		ctx,
+		"postgres:latest",
+		postgres.WithDatabase(c.Database),
+		postgres.WithUsername(c.User),
+		postgres.WithPassword(c.Password),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(5*time.Second)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	c.terminate = dbContainer.Terminate
+
+	host, err := dbContainer.Host(ctx)
+	if err != nil {
+		c.Terminate(ctx)
+		return nil, err
+	}
+
+	port, err := dbContainer.MappedPort(ctx, "5432/tcp")
+	if err != nil {
+		c.Terminate(ctx)
+		return nil, err
+	}
+
+	c.Host = host
+	c.Port = port.Port()
+
+	return c, nil
+}
+
+// Terminate stops and removes the container.
+func (c *Container) Terminate(ctx context.Context) error {
+	return c.terminate(ctx)
+}
+
+// Main is meant to be called from TestMain. It starts a container, hands it
+// to setup, runs the tests and then removes the container. When the
+// container cannot be started the run fails, unless SkipEnv is set, in
+// which case the tests run without setup being called and are expected to
+// skip themselves.
+func Main(m *testing.M, setup func(c *Container)) {
+	c, err := Start()
+	if err != nil {
+		if os.Getenv(SkipEnv) == "" {
+			log.Fatalf("could not start postgres container, set %s=1 to skip the database tests: %v", SkipEnv, err)
+		}
+
+		log.Printf("could not start postgres container, skipping database tests: %v", err)
+		os.Exit(m.Run())
+	}
+
+	setup(c)
+
+	code := m.Run()
+
+	if err := c.Terminate(context.Background()); err != nil {
+		log.Fatalf("could not teardown postgres container: %v", err)
+	}
+
+	os.Exit(code)
+}
@@ -0,0 +1,196 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// DefaultMaxTxRetries is how often a transaction is retried after a
+// serialization failure or deadlock when TxOptions does not say otherwise.
+const DefaultMaxTxRetries = 3
+
+// Backoff between retries, doubled on every attempt.
+const (
+	txRetryBaseDelay = 10 * time.Millisecond
+	txRetryMaxDelay  = 500 * time.Millisecond
+)
+
+// TxOptions configures a transaction started with InTx.
+type TxOptions struct {
+	Isolation sql.IsolationLevel
+	ReadOnly  bool
+
+	// MaxRetries bounds the retries after a serialization failure (40001)
+	// or deadlock (40P01). Zero uses DefaultMaxTxRetries and a negative
+	// value disables retrying.
+	MaxRetries int
+}
+
+func (o TxOptions) maxRetries() int {
+	switch {
+	case o.MaxRetries < 0:
+		return 0
+	case o.MaxRetries == 0:
+		return DefaultMaxTxRetries
+	}
+	return o.MaxRetries
+}
+
+// -----------------------------------------------------------------------------
+
+type txKey struct{}
+
+// txState is the transaction carried in a context.
+type txState struct {
+	tx         *sql.Tx
+	savepoints int
+}
+
+func txFromContext(ctx context.Context) *txState {
+	st, _ := ctx.Value(txKey{}).(*txState)
+	return st
+}
+
+// querier is satisfied by both *sql.DB and *sql.Tx.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// -----------------------------------------------------------------------------
+
+// InTx runs fn inside a transaction. The context passed to fn carries the
+// transaction, and the service's context methods called with it run inside
+// the transaction, so repositories join it without knowing about it.
+//
+// Called with a context that already carries a transaction, InTx creates a
+// savepoint instead: an error from fn rolls back to the savepoint and leaves
+// the outer transaction usable. opts only apply to the outermost call.
+//
+// The outermost call retries fn with backoff when the transaction fails with
+// a serialization failure or a deadlock, so fn must be safe to run again.
+func (s *service) InTx(ctx context.Context, opts TxOptions, fn func(ctx context.Context) error) error {
+	if st := txFromContext(ctx); st != nil {
+		return st.savepoint(ctx, fn)
+	}
+
+	delay := txRetryBaseDelay
+
+	for attempt := 0; ; attempt++ {
+		err := s.runTx(ctx, opts, fn)
+		if err == nil || !IsRetryable(err) || attempt >= opts.maxRetries() {
+			return err
+		}
+
+		// Full jitter keeps competing transactions from retrying in step.
+		wait := rand.N(delay) + time.Millisecond
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(wait):
+		}
+
+		delay = min(delay*2, txRetryMaxDelay)
+	}
+}
+
+// Transaction executes the function within a transaction.
+// If the function returns an error, the transaction is rolled back.
+// If the function returns nil, the transaction is committed.
+// It behaves as InTx with the default options.
+func (s *service) Transaction(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	return s.InTx(ctx, TxOptions{}, func(ctx context.Context) error {
+		return fn(txFromContext(ctx).tx)
+	})
+}
+
+// runTx runs one attempt of a transaction, observed by the hooks as a
+// single OpTx event from begin to commit or rollback.
+func (s *service) runTx(ctx context.Context, opts TxOptions, fn func(ctx context.Context) error) (err error) {
+	ctx, done := s.observe(ctx, OpTx, "", nil)
+	defer func() { done(err) }()
+
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{Isolation: opts.Isolation, ReadOnly: opts.ReadOnly})
+	if err != nil {
+		return fmt.Errorf("sqldb: begin: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, &txState{tx: tx})); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// savepoint runs fn inside a savepoint of the current transaction.
+func (st *txState) savepoint(ctx context.Context, fn func(ctx context.Context) error) error {
+	st.savepoints++
+	name := fmt.Sprintf("sp_%d", st.savepoints)
+
+	if _, err := st.tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("sqldb: savepoint: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			st.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name)
+			panic(p)
+		}
+	}()
+
+	if err := fn(ctx); err != nil {
+		if _, rbErr := st.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name); rbErr != nil {
+			return errors.Join(err, fmt.Errorf("sqldb: rollback to savepoint: %w", rbErr))
+		}
+		return err
+	}
+
+	if _, err := st.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("sqldb: release savepoint: %w", err)
+	}
+
+	return nil
+}
+
+// conn returns what a statement should run on: the transaction carried by
+// ctx, or the pool chosen by pick.
+func (s *service) conn(ctx context.Context, pick func(context.Context) *sql.DB) querier {
+	if st := txFromContext(ctx); st != nil {
+		return st.tx
+	}
+	return pick(ctx)
+}
+
+// -----------------------------------------------------------------------------
+
+// Postgres error codes worth retrying a whole transaction for.
+const (
+	codeSerializationFailure = "40001"
+	codeDeadlockDetected     = "40P01"
+)
+
+// IsRetryable reports whether err is a serialization failure or deadlock,
+// after which the transaction can be run again.
+func IsRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+
+	return pgErr.Code == codeSerializationFailure || pgErr.Code == codeDeadlockDetected
+}
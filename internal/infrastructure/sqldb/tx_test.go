@@ -0,0 +1,121 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{"Serialization", &pgconn.PgError{Code: "40001"}, true},
+		{"Deadlock", fmt.Errorf("update: %w", &pgconn.PgError{Code: "40P01"}), true},
+		{"UniqueViolation", &pgconn.PgError{Code: "23505"}, false},
+		{"Other", errors.New("boom"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestTxOptionsMaxRetries(t *testing.T) {
+	if got := (TxOptions{}).maxRetries(); got != DefaultMaxTxRetries {
+		t.Errorf("expected default of %d retries, got %d", DefaultMaxTxRetries, got)
+	}
+	if got := (TxOptions{MaxRetries: -1}).maxRetries(); got != 0 {
+		t.Errorf("expected retries to be disabled, got %d", got)
+	}
+}
+
+func TestInTx(t *testing.T) {
+	srv := openTestDB(t)
+	ctx := context.Background()
+
+	if _, err := srv.ExecuteQueryContext(ctx, `CREATE TABLE tx_test (id INT PRIMARY KEY)`); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { srv.ExecuteQueryContext(ctx, `DROP TABLE tx_test`) })
+
+	count := func(ctx context.Context) int {
+		var n int
+		if err := srv.QueryRowContext(ctx, `SELECT count(*) FROM tx_test`).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	errRollback := errors.New("rollback")
+
+	err := srv.InTx(ctx, TxOptions{Isolation: sql.LevelSerializable}, func(ctx context.Context) error {
+		if _, err := srv.ExecuteQueryContext(ctx, `INSERT INTO tx_test VALUES (1)`); err != nil {
+			return err
+		}
+
+		// The nested call fails and only its own insert is undone.
+		err := srv.InTx(ctx, TxOptions{}, func(ctx context.Context) error {
+			if _, err := srv.ExecuteQueryContext(ctx, `INSERT INTO tx_test VALUES (2)`); err != nil {
+				return err
+			}
+			return errRollback
+		})
+		if !errors.Is(err, errRollback) {
+			t.Errorf("expected the savepoint error, got %v", err)
+		}
+
+		if n := count(ctx); n != 1 {
+			t.Errorf("expected 1 row inside the transaction, got %d", n)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected commit, got %v", err)
+	}
+
+	if n := count(ctx); n != 1 {
+		t.Errorf("expected 1 committed row, got %d", n)
+	}
+
+	err = srv.InTx(ctx, TxOptions{}, func(ctx context.Context) error {
+		if _, err := srv.ExecuteQueryContext(ctx, `INSERT INTO tx_test VALUES (3)`); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatalf("expected rollback error, got %v", err)
+	}
+
+	if n := count(ctx); n != 1 {
+		t.Errorf("expected the failed transaction to leave 1 row, got %d", n)
+	}
+}
+
+func TestInTxRetry(t *testing.T) {
+	srv := openTestDB(t)
+
+	var attempts int
+	err := srv.InTx(context.Background(), TxOptions{MaxRetries: 2}, func(ctx context.Context) error {
+		attempts++
+		return &pgconn.PgError{Code: "40001"}
+	})
+
+	if !IsRetryable(err) {
+		t.Fatalf("expected the serialization failure to be returned, got %v", err)
+	}
+	if attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d", attempts)
+	}
+}
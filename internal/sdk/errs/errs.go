@@ -2,6 +2,7 @@
 package errs
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -51,22 +52,33 @@ func (ec ErrCode) Equal(ec2 ErrCode) bool {
 
 // Error represents an error in the system.
 type Error struct {
-	Code     ErrCode `json:"code"`
-	Message  string  `json:"message"`
-	FuncName string  `json:"-"`
-	FileName string  `json:"-"`
+	Code      ErrCode     `json:"code"`
+	Message   string      `json:"message"`
+	Fields    FieldErrors `json:"fields,omitempty"`
+	RequestID string      `json:"request_id,omitempty"`
+	TraceID   string      `json:"trace_id,omitempty"`
+	FuncName  string      `json:"-"`
+	FileName  string      `json:"-"`
 }
 
-// New constructs an error based on an app error.
+// New constructs an error based on an app error. If the error is a set of
+// FieldErrors, each field is carried in the error so it can be reported back.
 func New(code ErrCode, err error) *Error {
 	pc, filename, line, _ := runtime.Caller(1)
 
-	return &Error{
+	e := Error{
 		Code:     code,
 		Message:  err.Error(),
 		FuncName: runtime.FuncForPC(pc).Name(),
 		FileName: fmt.Sprintf("%s:%d", filename, line),
 	}
+
+	if fe := GetFieldErrors(err); fe != nil {
+		e.Message = "validation failed"
+		e.Fields = fe
+	}
+
+	return &e
 }
 
 // Newf constructs an error based on a error message.
@@ -82,13 +94,23 @@ func Newf(code ErrCode, format string, v ...any) *Error {
 }
 
 // NewError checks for an Error in the error interface value. If it doesn't
-// exist, will create one from the error.
+// exist, will create one from the error. Context errors keep their meaning
+// as DeadlineExceeded and Canceled.
 func NewError(err error) *Error {
 	var errsErr *Error
 	if errors.As(err, &errsErr) {
 		return errsErr
 	}
 
+	switch {
+	case IsFieldErrors(err):
+		return New(InvalidArgument, err)
+	case errors.Is(err, context.DeadlineExceeded):
+		return New(DeadlineExceeded, err)
+	case errors.Is(err, context.Canceled):
+		return New(Canceled, err)
+	}
+
 	return New(Internal, err)
 }
 
@@ -0,0 +1,42 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// errDiskUnsupported is returned on platforms without a disk usage probe.
+var errDiskUnsupported = errors.New("disk usage not supported on this platform")
+
+// DiskUsage describes the file system holding the checked path.
+type DiskUsage struct {
+	Path       string  `json:"path"`
+	TotalBytes uint64  `json:"total_bytes"`
+	FreeBytes  uint64  `json:"free_bytes"`
+	FreeRatio  float64 `json:"free_ratio"`
+}
+
+// DiskCheck reports the free space of the file system holding path. It is
+// degraded when less than minFree (a ratio between 0 and 1) is available and
+// down when the disk is full.
+func DiskCheck(path string, minFree float64) CheckFunc {
+	return func(ctx context.Context) Result {
+		usage, err := diskUsage(path)
+		if errors.Is(err, errDiskUnsupported) {
+			return Result{Status: StatusUp, Message: err.Error()}
+		}
+		if err != nil {
+			return Down(fmt.Errorf("disk usage: %w", err))
+		}
+
+		switch {
+		case usage.FreeBytes == 0:
+			return Result{Status: StatusDown, Message: "disk is full", Details: usage}
+		case usage.FreeRatio < minFree:
+			return Degraded(fmt.Sprintf("less than %.0f%% disk space left", minFree*100), usage)
+		}
+
+		return Up(usage)
+	}
+}
@@ -0,0 +1,7 @@
+//go:build !unix
+
+package health
+
+func diskUsage(path string) (DiskUsage, error) {
+	return DiskUsage{}, errDiskUnsupported
+}
@@ -0,0 +1,24 @@
+//go:build unix
+
+package health
+
+import "syscall"
+
+func diskUsage(path string) (DiskUsage, error) {
+	var fs syscall.Statfs_t
+	if err := syscall.Statfs(path, &fs); err != nil {
+		return DiskUsage{}, err
+	}
+
+	usage := DiskUsage{
+		Path:       path,
+		TotalBytes: uint64(fs.Blocks) * uint64(fs.Bsize),
+		FreeBytes:  uint64(fs.Bavail) * uint64(fs.Bsize),
+	}
+
+	if usage.TotalBytes > 0 {
+		usage.FreeRatio = float64(usage.FreeBytes) / float64(usage.TotalBytes)
+	}
+
+	return usage, nil
+}
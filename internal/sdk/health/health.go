@@ -0,0 +1,222 @@
+// Package health provides a registry of named health checks that are run
+// together and reported as a single typed result.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Status is the outcome of a check.
+type Status string
+
+// The set of statuses a check can report, from best to worst.
+const (
+	StatusUp       Status = "up"
+	StatusDegraded Status = "degraded"
+	StatusDown     Status = "down"
+)
+
+var severity = map[Status]int{
+	StatusUp:       0,
+	StatusDegraded: 1,
+	StatusDown:     2,
+}
+
+// worse returns the more severe of the two statuses.
+func worse(a Status, b Status) Status {
+	if severity[b] > severity[a] {
+		return b
+	}
+	return a
+}
+
+// DefaultTimeout bounds how long a single check may run.
+const DefaultTimeout = 2 * time.Second
+
+// -----------------------------------------------------------------------------
+
+// Result is what a check reports. Details holds check specific data and is
+// encoded as is.
+type Result struct {
+	Status  Status `json:"status"`
+	Message string `json:"message,omitempty"`
+	Details any    `json:"details,omitempty"`
+}
+
+// Up returns a passing result.
+func Up(details any) Result {
+	return Result{Status: StatusUp, Details: details}
+}
+
+// Degraded returns a result for a dependency that works but needs attention.
+func Degraded(message string, details any) Result {
+	return Result{Status: StatusDegraded, Message: message, Details: details}
+}
+
+// Down returns a failing result.
+func Down(err error) Result {
+	return Result{Status: StatusDown, Message: err.Error()}
+}
+
+// CheckFunc performs a single check. It must return once ctx is done.
+type CheckFunc func(ctx context.Context) Result
+
+// CheckResult is the result of a check together with how long it took.
+type CheckResult struct {
+	Result
+	Latency time.Duration `json:"-"`
+}
+
+// MarshalJSON adds the latency in a readable form.
+func (cr CheckResult) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Result
+		Latency string `json:"latency"`
+	}{
+		Result:  cr.Result,
+		Latency: cr.Latency.String(),
+	})
+}
+
+// Report is the combined outcome of every registered check. Its status is
+// the worst status of its checks.
+type Report struct {
+	Status Status                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// Encode implements the encoder interface.
+func (r Report) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(r)
+	return data, "application/json", err
+}
+
+// -----------------------------------------------------------------------------
+
+type check struct {
+	name string
+	fn   CheckFunc
+}
+
+// Registry holds the named checks of the service. It is safe for concurrent
+// use.
+type Registry struct {
+	mu       sync.RWMutex
+	checks   []check
+	timeout  time.Duration
+	draining atomic.Bool
+}
+
+// NewRegistry returns an empty registry whose checks are each limited to
+// timeout. A zero timeout uses DefaultTimeout.
+func NewRegistry(timeout time.Duration) *Registry {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	return &Registry{
+		timeout: timeout,
+	}
+}
+
+// Register adds a named check. It panics if the name is already taken, the
+// same way http.ServeMux treats duplicate patterns.
+func (r *Registry) Register(name string, fn CheckFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, c := range r.checks {
+		if c.name == name {
+			panic(fmt.Sprintf("health: check %q already registered", name))
+		}
+	}
+
+	r.checks = append(r.checks, check{name: name, fn: fn})
+	sort.Slice(r.checks, func(i, j int) bool {
+		return r.checks[i].name < r.checks[j].name
+	})
+}
+
+// Drain marks the service as shutting down so readiness probes fail and
+// load balancers stop sending traffic before the server stops accepting it.
+func (r *Registry) Drain() {
+	r.draining.Store(true)
+}
+
+// Draining reports whether Drain has been called.
+func (r *Registry) Draining() bool {
+	return r.draining.Load()
+}
+
+// Run executes every check concurrently and combines the results. A check
+// that panics or does not finish in time is reported as down.
+func (r *Registry) Run(ctx context.Context) Report {
+	// Register sorts the slice in place, so Run works on a copy.
+	r.mu.RLock()
+	checks := slices.Clone(r.checks)
+	r.mu.RUnlock()
+
+	results := make([]CheckResult, len(checks))
+
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = r.run(ctx, c.fn)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{
+		Status: StatusUp,
+		Checks: make(map[string]CheckResult, len(checks)),
+	}
+
+	for i, c := range checks {
+		report.Checks[c.name] = results[i]
+		report.Status = worse(report.Status, results[i].Status)
+	}
+
+	return report
+}
+
+func (r *Registry) run(ctx context.Context, fn CheckFunc) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan Result, 1)
+
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- Down(fmt.Errorf("check panicked: %v", p))
+			}
+		}()
+		done <- fn(ctx)
+	}()
+
+	var res Result
+	select {
+	case res = <-done:
+	case <-ctx.Done():
+		res = Down(fmt.Errorf("check did not finish: %w", ctx.Err()))
+	}
+
+	if _, ok := severity[res.Status]; !ok {
+		res.Status = StatusDown
+	}
+
+	return CheckResult{
+		Result:  res,
+		Latency: time.Since(start),
+	}
+}
@@ -0,0 +1,151 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func Test_Registry(t *testing.T) {
+	t.Parallel()
+
+	up := func(ctx context.Context) Result { return Up(nil) }
+	degraded := func(ctx context.Context) Result { return Degraded("slow", nil) }
+	down := func(ctx context.Context) Result { return Down(errors.New("unreachable")) }
+	panics := func(ctx context.Context) Result { panic("boom") }
+	hangs := func(ctx context.Context) Result {
+		time.Sleep(time.Second)
+		return Up(nil)
+	}
+
+	tests := []struct {
+		name     string
+		checks   map[string]CheckFunc
+		expected Status
+	}{
+		{"Empty", nil, StatusUp},
+		{"AllUp", map[string]CheckFunc{"a": up, "b": up}, StatusUp},
+		{"Degraded", map[string]CheckFunc{"a": up, "b": degraded}, StatusDegraded},
+		{"DownWins", map[string]CheckFunc{"a": degraded, "b": down}, StatusDown},
+		{"Panic", map[string]CheckFunc{"a": up, "b": panics}, StatusDown},
+		{"Timeout", map[string]CheckFunc{"a": hangs}, StatusDown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			reg := NewRegistry(50 * time.Millisecond)
+			for name, fn := range tt.checks {
+				reg.Register(name, fn)
+			}
+
+			report := reg.Run(context.Background())
+			if report.Status != tt.expected {
+				t.Errorf("Expected status %s, got %s", tt.expected, report.Status)
+			}
+			if len(report.Checks) != len(tt.checks) {
+				t.Errorf("Expected %d check results, got %d", len(tt.checks), len(report.Checks))
+			}
+		})
+	}
+}
+
+func Test_RegisterDuplicate(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a panic on duplicate registration")
+		}
+	}()
+
+	reg := NewRegistry(0)
+	reg.Register("db", func(ctx context.Context) Result { return Up(nil) })
+	reg.Register("db", func(ctx context.Context) Result { return Up(nil) })
+}
+
+// Test_RegisterWhileRunning is meant for -race: Register reorders the
+// checks in place while Run may be reading them.
+func Test_RegisterWhileRunning(t *testing.T) {
+	t.Parallel()
+
+	up := func(ctx context.Context) Result { return Up(nil) }
+
+	reg := NewRegistry(0)
+
+	const n = 100
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		for i := range n {
+			// Each name sorts first, so every append moves the others.
+			reg.Register(fmt.Sprintf("check-%03d", n-i), up)
+			time.Sleep(100 * time.Microsecond)
+		}
+	}()
+
+	for {
+		select {
+		case <-done:
+			if report := reg.Run(context.Background()); len(report.Checks) != n {
+				t.Errorf("Expected %d check results, got %d", n, len(report.Checks))
+			}
+			return
+		default:
+		}
+
+		if report := reg.Run(context.Background()); report.Status != StatusUp {
+			t.Fatalf("Expected status %s, got %s", StatusUp, report.Status)
+		}
+	}
+}
+
+func Test_ReportEncode(t *testing.T) {
+	t.Parallel()
+
+	reg := NewRegistry(0)
+	reg.Register("db", func(ctx context.Context) Result {
+		return Degraded("pool nearly exhausted", map[string]int{"in_use": 9})
+	})
+
+	data, _, err := reg.Run(context.Background()).Encode()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var got struct {
+		Status string `json:"status"`
+		Checks map[string]struct {
+			Status  string         `json:"status"`
+			Message string         `json:"message"`
+			Latency string         `json:"latency"`
+			Details map[string]int `json:"details"`
+		} `json:"checks"`
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Expected valid JSON, got %v", err)
+	}
+
+	db := got.Checks["db"]
+	if got.Status != "degraded" || db.Status != "degraded" || db.Latency == "" || db.Details["in_use"] != 9 {
+		t.Errorf("Unexpected report %s", data)
+	}
+}
+
+func Test_DiskCheck(t *testing.T) {
+	t.Parallel()
+
+	if got := DiskCheck(t.TempDir(), 0)(context.Background()); got.Status != StatusUp {
+		t.Errorf("Expected status up, got %s: %s", got.Status, got.Message)
+	}
+
+	if got := DiskCheck(t.TempDir(), 1.1)(context.Background()); got.Status != StatusDegraded {
+		t.Errorf("Expected status degraded, got %s: %s", got.Status, got.Message)
+	}
+}
@@ -0,0 +1,94 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func Test_MergePatch(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		doc      string
+		patch    string
+		expected string
+	}{
+		{"ReplaceMember", `{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{"AddMember", `{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{"RemoveMember", `{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{"Nested", `{"a":{"b":"c","d":"e"}}`, `{"a":{"d":null,"f":"g"}}`, `{"a":{"b":"c","f":"g"}}`},
+		{"ReplaceArray", `{"a":["b"]}`, `{"a":["c","d"]}`, `{"a":["c","d"]}`},
+		{"ReplaceDocument", `{"a":"b"}`, `["c"]`, `["c"]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MergePatch([]byte(tt.doc), []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			assertJSONEqual(t, tt.expected, string(got))
+		})
+	}
+}
+
+func Test_Apply(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		doc      string
+		patch    string
+		expected string
+		err      bool
+	}{
+		{"Add", `{"a":1}`, `[{"op":"add","path":"/b","value":2}]`, `{"a":1,"b":2}`, false},
+		{"AddArrayIndex", `{"a":[1,3]}`, `[{"op":"add","path":"/a/1","value":2}]`, `{"a":[1,2,3]}`, false},
+		{"AddArrayEnd", `{"a":[1]}`, `[{"op":"add","path":"/a/-","value":2}]`, `{"a":[1,2]}`, false},
+		{"Remove", `{"a":1,"b":2}`, `[{"op":"remove","path":"/a"}]`, `{"b":2}`, false},
+		{"RemoveMissing", `{"a":1}`, `[{"op":"remove","path":"/b"}]`, ``, true},
+		{"Replace", `{"a":1}`, `[{"op":"replace","path":"/a","value":"x"}]`, `{"a":"x"}`, false},
+		{"ReplaceMissing", `{"a":1}`, `[{"op":"replace","path":"/b","value":"x"}]`, ``, true},
+		{"Move", `{"a":{"b":1}}`, `[{"op":"move","from":"/a/b","path":"/c"}]`, `{"a":{},"c":1}`, false},
+		{"MoveIntoChild", `{"a":{"b":1}}`, `[{"op":"move","from":"/a","path":"/a/b/c"}]`, ``, true},
+		{"Copy", `{"a":{"b":1}}`, `[{"op":"copy","from":"/a","path":"/c"}]`, `{"a":{"b":1},"c":{"b":1}}`, false},
+		{"TestPass", `{"a":"b"}`, `[{"op":"test","path":"/a","value":"b"},{"op":"replace","path":"/a","value":"c"}]`, `{"a":"c"}`, false},
+		{"TestFail", `{"a":"b"}`, `[{"op":"test","path":"/a","value":"x"}]`, ``, true},
+		{"EscapedPointer", `{"a/b":1,"c~d":2}`, `[{"op":"remove","path":"/a~1b"},{"op":"remove","path":"/c~0d"}]`, `{}`, false},
+		{"UnknownOp", `{"a":1}`, `[{"op":"merge","path":"/a"}]`, ``, true},
+		{"MissingValue", `{"a":1}`, `[{"op":"add","path":"/b"}]`, ``, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Apply([]byte(tt.doc), []byte(tt.patch))
+			if tt.err {
+				if err == nil {
+					t.Fatalf("Expected error, got %s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			assertJSONEqual(t, tt.expected, string(got))
+		})
+	}
+}
+
+func assertJSONEqual(t *testing.T, expected string, got string) {
+	t.Helper()
+
+	var e, g any
+	if err := json.Unmarshal([]byte(expected), &e); err != nil {
+		t.Fatalf("invalid expected JSON: %v", err)
+	}
+	if err := json.Unmarshal([]byte(got), &g); err != nil {
+		t.Fatalf("invalid JSON result: %v", err)
+	}
+	if !reflect.DeepEqual(e, g) {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+}
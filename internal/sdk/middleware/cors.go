@@ -1,22 +1,277 @@
 package middleware
 
-import "net/http"
-
-func CORS(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Set CORS headers
-		w.Header().Set("Access-Control-Allow-Origin", "*") // Replace "*" with specific origins if needed
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS, PATCH")
-		w.Header().Set("Access-Control-Allow-Headers", "Accept, Authorization, Content-Type, X-CSRF-Token")
-		w.Header().Set("Access-Control-Allow-Credentials", "false") // Set to "true" if credentials are required
-
-		// Handle preflight OPTIONS requests
-		if r.Method == http.MethodOptions {
-			w.WriteHeader(http.StatusNoContent)
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSConfig configures the CORS middleware.
+type CORSConfig struct {
+	// AllowedOrigins lists the origins allowed to make cross-origin
+	// requests. An entry is an exact origin such as https://app.example.com,
+	// a wildcard subdomain such as https://*.example.com, or "*" for any
+	// origin.
+	AllowedOrigins []string
+
+	// AllowedOriginPatterns are matched against the whole origin, in
+	// addition to AllowedOrigins.
+	AllowedOriginPatterns []*regexp.Regexp
+
+	AllowedMethods []string
+	AllowedHeaders []string
+	ExposedHeaders []string
+
+	// AllowCredentials lets browsers send cookies and authorization headers.
+	// It requires explicit origins, as the allowed origin is echoed back.
+	AllowCredentials bool
+
+	// MaxAge is how long browsers may cache a preflight response. Zero
+	// leaves it to the browser.
+	MaxAge time.Duration
+}
+
+// DefaultCORSConfig allows any origin to use the API without credentials.
+func DefaultCORSConfig() CORSConfig {
+	return CORSConfig{
+		AllowedOrigins: []string{"*"},
+		AllowedMethods: []string{
+			http.MethodGet,
+			http.MethodPost,
+			http.MethodPut,
+			http.MethodPatch,
+			http.MethodDelete,
+		},
+		AllowedHeaders: []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "If-Match", "If-None-Match", RequestIDHeader, TraceParentHeader},
+		ExposedHeaders: []string{"ETag", "Location", RequestIDHeader, TraceParentHeader},
+	}
+}
+
+// CORSConfigFromEnv builds a CORSConfig from DefaultCORSConfig and the
+// CORS_* environment variables. Lists are comma separated.
+func CORSConfigFromEnv() (CORSConfig, error) {
+	cfg := DefaultCORSConfig()
+
+	var errs []error
+
+	list := func(key string, dst *[]string) {
+		value := os.Getenv(key)
+		if value == "" {
 			return
 		}
+		*dst = nil
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				*dst = append(*dst, item)
+			}
+		}
+	}
+
+	list("CORS_ALLOWED_ORIGINS", &cfg.AllowedOrigins)
+	list("CORS_ALLOWED_METHODS", &cfg.AllowedMethods)
+	list("CORS_ALLOWED_HEADERS", &cfg.AllowedHeaders)
+	list("CORS_EXPOSED_HEADERS", &cfg.ExposedHeaders)
+
+	var patterns []string
+	list("CORS_ALLOWED_ORIGIN_PATTERNS", &patterns)
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("CORS_ALLOWED_ORIGIN_PATTERNS: %w", err))
+			continue
+		}
+		cfg.AllowedOriginPatterns = append(cfg.AllowedOriginPatterns, re)
+	}
+
+	if value := os.Getenv("CORS_ALLOW_CREDENTIALS"); value != "" {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("CORS_ALLOW_CREDENTIALS: %w", err))
+		}
+		cfg.AllowCredentials = b
+	}
+
+	if value := os.Getenv("CORS_MAX_AGE"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("CORS_MAX_AGE: %w", err))
+		}
+		cfg.MaxAge = d
+	}
+
+	if len(errs) > 0 {
+		return CORSConfig{}, fmt.Errorf("cors: config: %w", errors.Join(errs...))
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return CORSConfig{}, err
+	}
+
+	return cfg, nil
+}
+
+// Validate reports settings the middleware cannot honour safely.
+func (c CORSConfig) Validate() error {
+	var errs []error
+
+	if c.AllowCredentials && slices.Contains(c.AllowedOrigins, "*") {
+		errs = append(errs, errors.New("credentials cannot be allowed for any origin"))
+	}
+
+	for _, origin := range c.AllowedOrigins {
+		if strings.Count(origin, "*") > 1 || (origin != "*" && strings.Contains(origin, "*") && !strings.Contains(origin, "://*.")) {
+			errs = append(errs, fmt.Errorf("origin %q: only a leading subdomain wildcard is supported", origin))
+		}
+	}
+
+	if c.MaxAge < 0 {
+		errs = append(errs, errors.New("max age must not be negative"))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("cors: invalid config: %w", errors.Join(errs...))
+	}
+
+	return nil
+}
+
+// allowOrigin reports whether origin may make cross-origin requests.
+func (c CORSConfig) allowOrigin(origin string) bool {
+	for _, allowed := range c.AllowedOrigins {
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+
+		// https://*.example.com matches any subdomain, but not
+		// https://example.com itself.
+		if prefix, suffix, ok := strings.Cut(allowed, "*"); ok {
+			if len(origin) > len(prefix)+len(suffix) && strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) {
+				return true
+			}
+		}
+	}
+
+	for _, re := range c.AllowedOriginPatterns {
+		if re.MatchString(origin) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// -----------------------------------------------------------------------------
+
+// Router finds the handler for a request, as http.ServeMux does.
+type Router interface {
+	Handler(r *http.Request) (h http.Handler, pattern string)
+}
+
+// CORS returns the middleware answering preflight requests and adding the
+// CORS headers for allowed origins. It runs before routing, and asks router
+// which of the allowed methods are registered for the requested path:
+// preflights for unknown paths get a 404, and for methods the path does not
+// serve a 405. Requests from origins that are not allowed are served
+// without CORS headers, which makes the browser block the response.
+func CORS(cfg CORSConfig, router Router) Middleware {
+	allowHeaders := strings.Join(cfg.AllowedHeaders, ", ")
+	exposeHeaders := strings.Join(cfg.ExposedHeaders, ", ")
+	anyOrigin := slices.Contains(cfg.AllowedOrigins, "*") && !cfg.AllowCredentials
+
+	var maxAge string
+	if cfg.MaxAge > 0 {
+		maxAge = strconv.Itoa(int(cfg.MaxAge.Seconds()))
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// The response depends on the Origin even when it is not
+			// allowed, so caches must not share it across origins.
+			w.Header().Add("Vary", "Origin")
+
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+			if !cfg.allowOrigin(origin) {
+				if preflight {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			if anyOrigin {
+				h.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				h.Set("Access-Control-Allow-Origin", origin)
+			}
+			if cfg.AllowCredentials {
+				h.Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			if !preflight {
+				if exposeHeaders != "" {
+					h.Set("Access-Control-Expose-Headers", exposeHeaders)
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+
+			methods := routeMethods(router, r, cfg.AllowedMethods)
+			if len(methods) == 0 {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+
+			if !slices.Contains(methods, r.Header.Get("Access-Control-Request-Method")) {
+				h.Set("Allow", strings.Join(methods, ", "))
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+
+			h.Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+			if allowHeaders != "" {
+				h.Set("Access-Control-Allow-Headers", allowHeaders)
+			}
+			if maxAge != "" {
+				h.Set("Access-Control-Max-Age", maxAge)
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
+
+// routeMethods returns the methods, out of allowed, that router has a route
+// for at the path of r. A pattern without a method, such as a "/" catch-all,
+// matches every method and path, so it makes every preflight pass.
+func routeMethods(router Router, r *http.Request, allowed []string) []string {
+	var methods []string
+
+	for _, method := range allowed {
+		probe := r.Clone(r.Context())
+		probe.Method = method
+
+		if _, pattern := router.Handler(probe); pattern != "" {
+			methods = append(methods, method)
+		}
+	}
 
-		// Proceed with the next handler
-		next.ServeHTTP(w, r)
-	})
+	return methods
 }
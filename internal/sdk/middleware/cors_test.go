@@ -0,0 +1,201 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"testing"
+	"time"
+)
+
+func Test_CORSConfigAllowOrigin(t *testing.T) {
+	t.Parallel()
+
+	cfg := CORSConfig{
+		AllowedOrigins:        []string{"https://app.example.com", "https://*.example.org"},
+		AllowedOriginPatterns: []*regexp.Regexp{regexp.MustCompile(`^http://localhost:\d+$`)},
+	}
+
+	tests := []struct {
+		origin   string
+		expected bool
+	}{
+		{"https://app.example.com", true},
+		{"https://api.example.com", false},
+		{"https://a.example.org", true},
+		{"https://a.b.example.org", true},
+		{"https://example.org", false},
+		{"http://a.example.org", false},
+		{"http://localhost:5173", true},
+		{"http://localhost", false},
+	}
+
+	for _, tt := range tests {
+		if got := cfg.allowOrigin(tt.origin); got != tt.expected {
+			t.Errorf("Expected %s allowed to be %v, got %v", tt.origin, tt.expected, got)
+		}
+	}
+}
+
+func Test_CORSConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		cfg  CORSConfig
+		err  bool
+	}{
+		{"Default", DefaultCORSConfig(), false},
+		{"CredentialsWithOrigins", CORSConfig{AllowedOrigins: []string{"https://*.example.com"}, AllowCredentials: true}, false},
+		{"CredentialsWithAnyOrigin", CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true}, true},
+		{"InnerWildcard", CORSConfig{AllowedOrigins: []string{"https://app.*.com"}}, true},
+		{"NegativeMaxAge", CORSConfig{MaxAge: -time.Second}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tt.cfg.Validate()
+			if tt.err && err == nil {
+				t.Fatal("Expected error, got nil")
+			}
+			if !tt.err && err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func Test_CORS(t *testing.T) {
+	t.Parallel()
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /todo/{id}", ok)
+	mux.Handle("PUT /todo/{id}", ok)
+	mux.Handle("DELETE /todo/{id}", ok)
+
+	cfg := DefaultCORSConfig()
+	cfg.AllowedOrigins = []string{"https://app.example.com"}
+	cfg.AllowCredentials = true
+	cfg.MaxAge = 10 * time.Minute
+
+	h := CORS(cfg, mux)(mux)
+
+	tests := []struct {
+		name          string
+		method        string
+		target        string
+		origin        string
+		requestMethod string
+		status        int
+		headers       map[string]string
+	}{
+		{
+			name:   "Simple",
+			method: http.MethodGet, target: "/todo/7", origin: "https://app.example.com",
+			status: http.StatusOK,
+			headers: map[string]string{
+				"Access-Control-Allow-Origin":      "https://app.example.com",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Expose-Headers":    "ETag, Location, X-Request-ID, traceparent",
+			},
+		},
+		{
+			name:   "NoOrigin",
+			method: http.MethodGet, target: "/todo/7",
+			status:  http.StatusOK,
+			headers: map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		{
+			name:   "OriginNotAllowed",
+			method: http.MethodGet, target: "/todo/7", origin: "https://evil.example.com",
+			status:  http.StatusOK,
+			headers: map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		{
+			name:   "Preflight",
+			method: http.MethodOptions, target: "/todo/7", origin: "https://app.example.com", requestMethod: http.MethodPut,
+			status: http.StatusNoContent,
+			headers: map[string]string{
+				"Access-Control-Allow-Origin":  "https://app.example.com",
+				"Access-Control-Allow-Methods": "GET, PUT, DELETE",
+				"Access-Control-Allow-Headers": "Accept, Authorization, Content-Type, X-CSRF-Token, If-Match, If-None-Match, X-Request-ID, traceparent",
+				"Access-Control-Max-Age":       "600",
+			},
+		},
+		{
+			name:   "PreflightMethodNotRegistered",
+			method: http.MethodOptions, target: "/todo/7", origin: "https://app.example.com", requestMethod: http.MethodPatch,
+			status:  http.StatusMethodNotAllowed,
+			headers: map[string]string{"Allow": "GET, PUT, DELETE", "Access-Control-Allow-Methods": ""},
+		},
+		{
+			name:   "PreflightUnknownRoute",
+			method: http.MethodOptions, target: "/missing", origin: "https://app.example.com", requestMethod: http.MethodGet,
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "PreflightOriginNotAllowed",
+			method: http.MethodOptions, target: "/todo/7", origin: "https://evil.example.com", requestMethod: http.MethodGet,
+			status:  http.StatusForbidden,
+			headers: map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(tt.method, tt.target, nil)
+			if tt.origin != "" {
+				r.Header.Set("Origin", tt.origin)
+			}
+			if tt.requestMethod != "" {
+				r.Header.Set("Access-Control-Request-Method", tt.requestMethod)
+			}
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if w.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, w.Code)
+			}
+
+			for k, v := range tt.headers {
+				if got := w.Header().Get(k); got != v {
+					t.Errorf("Expected %s to be %q, got %q", k, v, got)
+				}
+			}
+
+			if !slices.Contains(w.Header().Values("Vary"), "Origin") {
+				t.Errorf("Expected Vary: Origin, got %v", w.Header().Values("Vary"))
+			}
+		})
+	}
+}
+
+func Test_CORSAnyOrigin(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /todo/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	h := CORS(DefaultCORSConfig(), mux)(mux)
+
+	r := httptest.NewRequest(http.MethodGet, "/todo/7", nil)
+	r.Header.Set("Origin", "https://anywhere.example.com")
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Expected *, got %q", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Errorf("Expected no credentials header, got %q", got)
+	}
+}
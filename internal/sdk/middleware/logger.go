@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/BuildFrom/Golang-Stdlib/internal/sdk/trace"
+)
+
+// NewLogger returns a logger writing to w in the given format, "json" or
+// "text". Any other format falls back to text. Records logged with a
+// request context carry its request and trace IDs.
+func NewLogger(w io.Writer, format string, level slog.Level) *slog.Logger {
+	opts := slog.HandlerOptions{Level: level}
+
+	var h slog.Handler = slog.NewTextHandler(w, &opts)
+	if strings.EqualFold(format, "json") {
+		h = slog.NewJSONHandler(w, &opts)
+	}
+
+	return slog.New(trace.NewLogHandler(h))
+}
+
+// LoggerFromEnv returns a logger writing to stdout in the format set by
+// LOG_FORMAT and at the level set by LOG_LEVEL, text at info by default.
+func LoggerFromEnv() *slog.Logger {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(os.Getenv("LOG_LEVEL"))); err != nil {
+		level = slog.LevelInfo
+	}
+
+	return NewLogger(os.Stdout, os.Getenv("LOG_FORMAT"), level)
+}
+
+// Logger returns the middleware logging one line per request once it has
+// been served. Server errors are logged at error level and everything else
+// at info.
+//
+// It is meant to wrap the mux, so it also logs requests no route matched.
+// The route pattern is read after the mux has set it on the request. The
+// request ID is added by a logger from NewLogger, when RequestID runs first.
+func Logger(log *slog.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			rw := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rw, r)
+
+			level := slog.LevelInfo
+			if rw.status >= http.StatusInternalServerError {
+				level = slog.LevelError
+			}
+
+			log.LogAttrs(r.Context(), level, "request",
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.String("pattern", r.Pattern),
+				slog.Int("status", rw.status),
+				slog.Int64("bytes", rw.bytes),
+				slog.Duration("latency", time.Since(start)),
+				slog.String("remote_ip", remoteIP(r)),
+				slog.String("user_agent", r.UserAgent()),
+			)
+		})
+	}
+}
+
+// remoteIP returns the address of the peer, without the port.
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// -----------------------------------------------------------------------------
+
+// responseRecorder captures the status and size of a response.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+}
+
+func (rw *responseRecorder) WriteHeader(status int) {
+	if !rw.wroteHeader {
+		rw.status = status
+		rw.wroteHeader = true
+	}
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *responseRecorder) Write(b []byte) (int, error) {
+	rw.wroteHeader = true
+	n, err := rw.ResponseWriter.Write(b)
+	rw.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, to
+// flush or set deadlines.
+func (rw *responseRecorder) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_Logger(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /todo/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("hello"))
+	})
+	mux.HandleFunc("GET /fail", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	})
+
+	tests := []struct {
+		name    string
+		target  string
+		level   string
+		pattern string
+		status  float64
+		bytes   float64
+	}{
+		{"Route", "/todo/7", "INFO", "GET /todo/{id}", http.StatusCreated, 5},
+		{"ServerError", "/fail", "ERROR", "GET /fail", http.StatusInternalServerError, 5},
+		{"NotFound", "/missing", "INFO", "", http.StatusNotFound, 19},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			h := WrapMiddleware(mux, RequestID(), Logger(NewLogger(&buf, "json", slog.LevelInfo)))
+
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			r.RemoteAddr = "10.0.0.1:5555"
+			r.Header.Set("User-Agent", "test")
+			r.Header.Set("X-Request-ID", "abc")
+			h.ServeHTTP(httptest.NewRecorder(), r)
+
+			var entry map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+				t.Fatalf("Expected one JSON log line, got %q: %v", buf.String(), err)
+			}
+
+			expected := map[string]any{
+				"level":      tt.level,
+				"msg":        "request",
+				"method":     http.MethodGet,
+				"path":       tt.target,
+				"pattern":    tt.pattern,
+				"status":     tt.status,
+				"bytes":      tt.bytes,
+				"remote_ip":  "10.0.0.1",
+				"user_agent": "test",
+				"request_id": "abc",
+			}
+			for k, v := range expected {
+				if entry[k] != v {
+					t.Errorf("Expected %s to be %v, got %v", k, v, entry[k])
+				}
+			}
+
+			for _, k := range []string{"latency", "trace_id", "span_id"} {
+				if _, ok := entry[k]; !ok {
+					t.Errorf("Expected %s to be logged", k)
+				}
+			}
+		})
+	}
+}
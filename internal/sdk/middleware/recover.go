@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"errors"
+	"expvar"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/BuildFrom/Golang-Stdlib/internal/sdk/errs"
+	"github.com/BuildFrom/Golang-Stdlib/internal/sdk/web"
+)
+
+// panics counts the panics recovered by Recover, published as the "panics"
+// expvar.
+var panics = expvar.NewInt("panics")
+
+// Recover returns the middleware that turns a panic in a later handler into
+// a JSON errs.Internal response, written by web.RespondError. The panic is
+// logged with its stack and counted in the "panics" expvar. When the handler
+// had already started the response, the rest of it is abandoned, since the
+// status can no longer be changed.
+//
+// It should run after RequestID, so the log line and the response carry
+// the request ID, and after Logger, so the request is logged as a 500.
+func Recover(log *slog.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rw := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+
+				// net/http aborts the response quietly for this one.
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+
+				panics.Add(1)
+
+				ctx := r.Context()
+				log.ErrorContext(ctx, "panic",
+					"panic", fmt.Sprint(rec),
+					"method", r.Method,
+					"path", r.URL.Path,
+					"stack", string(debug.Stack()),
+				)
+
+				if rw.wroteHeader {
+					return
+				}
+
+				web.RespondError(ctx, log, w, errs.New(errs.Internal, errors.New(http.StatusText(http.StatusInternalServerError))))
+			}()
+
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
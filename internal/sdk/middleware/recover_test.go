@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Test_Recover does not run in parallel, as it reads the shared panics counter.
+func Test_Recover(t *testing.T) {
+	var buf bytes.Buffer
+	log := NewLogger(&buf, "text", slog.LevelInfo)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /panic", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"1"`)
+		panic("boom")
+	})
+	mux.HandleFunc("GET /partial", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("partial"))
+		panic("boom")
+	})
+
+	h := WrapMiddleware(mux, RequestID(), Logger(log), Recover(log))
+
+	before := panics.Value()
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+
+	if got := w.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("Expected a JSON error body, got content type %q", got)
+	}
+
+	var body struct {
+		Code      string `json:"code"`
+		RequestID string `json:"request_id"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Expected JSON error body, got %q: %v", w.Body.String(), err)
+	}
+	if body.Code != "internal" {
+		t.Errorf("Expected code internal, got %q", body.Code)
+	}
+	if id := w.Header().Get(RequestIDHeader); body.RequestID == "" || body.RequestID != id {
+		t.Errorf("Expected request ID %q in the body, got %q", id, body.RequestID)
+	}
+
+	out := buf.String()
+	for _, s := range []string{"msg=panic", "panic=boom", "request_id=" + body.RequestID, "recover_test.go", "status=500"} {
+		if !strings.Contains(out, s) {
+			t.Errorf("Expected %q in the log, got %q", s, out)
+		}
+	}
+
+	// A response that already started is left as it is.
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/partial", nil))
+
+	if w.Code != http.StatusOK || w.Body.String() != "partial" {
+		t.Errorf("Expected the partial response, got %d %q", w.Code, w.Body.String())
+	}
+
+	if got := panics.Value() - before; got != 2 {
+		t.Errorf("Expected 2 panics counted, got %d", got)
+	}
+}
+
+func Test_RecoverAbortHandler(t *testing.T) {
+	t.Parallel()
+
+	h := Recover(slog.Default())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	defer func() {
+		if rec := recover(); rec != http.ErrAbortHandler {
+			t.Errorf("Expected http.ErrAbortHandler to be passed on, got %v", rec)
+		}
+	}()
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
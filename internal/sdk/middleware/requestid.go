@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/BuildFrom/Golang-Stdlib/internal/sdk/trace"
+)
+
+// Headers read and written by the RequestID middleware.
+const (
+	RequestIDHeader   = "X-Request-ID"
+	TraceParentHeader = "traceparent"
+)
+
+// RequestID returns the middleware that identifies every request. It keeps
+// a valid X-Request-ID sent by the client or generates one, and continues
+// the W3C trace of a valid traceparent header or starts a new trace. Both
+// are stored in the request context, see the trace package, and written to
+// the response headers, the traceparent naming the span of this request.
+//
+// It should be the outermost middleware so everything after it, including
+// the request log, can use them.
+func RequestID() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(RequestIDHeader)
+			if !trace.ValidRequestID(id) {
+				id = trace.NewRequestID()
+			}
+
+			parent, err := trace.ParseParent(r.Header.Get(TraceParentHeader))
+			if err != nil {
+				parent = trace.NewParent()
+			} else {
+				parent = parent.Child()
+			}
+
+			w.Header().Set(RequestIDHeader, id)
+			w.Header().Set(TraceParentHeader, parent.String())
+
+			ctx := trace.WithRequestID(r.Context(), id)
+			ctx = trace.WithParent(ctx, parent)
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/BuildFrom/Golang-Stdlib/internal/sdk/trace"
+)
+
+func Test_RequestID(t *testing.T) {
+	t.Parallel()
+
+	const parent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	tests := []struct {
+		name        string
+		requestID   string
+		traceparent string
+		keepID      bool
+		keepTrace   bool
+	}{
+		{"Propagated", "abc-123", parent, true, true},
+		{"Generated", "", "", false, false},
+		{"Invalid", "not valid", "00-garbage", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var id string
+			var p trace.Parent
+
+			h := RequestID()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				id = trace.RequestID(r.Context())
+				p, _ = trace.ParentFromContext(r.Context())
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.requestID != "" {
+				r.Header.Set(RequestIDHeader, tt.requestID)
+			}
+			if tt.traceparent != "" {
+				r.Header.Set(TraceParentHeader, tt.traceparent)
+			}
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if !trace.ValidRequestID(id) || (tt.keepID && id != tt.requestID) || (!tt.keepID && id == tt.requestID) {
+				t.Errorf("Unexpected request ID %q", id)
+			}
+			if got := w.Header().Get(RequestIDHeader); got != id {
+				t.Errorf("Expected the request ID %q echoed, got %q", id, got)
+			}
+
+			if got := w.Header().Get(TraceParentHeader); got != p.String() {
+				t.Errorf("Expected the traceparent %q echoed, got %q", p, got)
+			}
+			if sameTrace := strings.HasPrefix(p.String(), parent[:36]); sameTrace != tt.keepTrace {
+				t.Errorf("Expected continuing the trace to be %v, got %s", tt.keepTrace, p)
+			}
+			if p.String() == parent {
+				t.Error("Expected a span of its own")
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+// Package order provides support for describing the ordering of data.
+package order
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Set of directions for data ordering.
+const (
+	ASC  = "ASC"
+	DESC = "DESC"
+)
+
+var directions = map[string]string{
+	ASC:  "ASC",
+	DESC: "DESC",
+}
+
+// =============================================================================
+
+// By represents a field used to order by and direction.
+type By struct {
+	Field     string
+	Direction string
+}
+
+// NewBy constructs a new By value with no checks.
+func NewBy(field string, direction string) By {
+	return By{
+		Field:     field,
+		Direction: direction,
+	}
+}
+
+// Parse constructs a By value by parsing a string in the form of
+// "field,direction" ie "created_at,desc". Only fields present in the
+// fieldMappings are accepted, so the result is safe to use as SQL.
+func Parse(fieldMappings map[string]string, orderBy string, defaultOrder By) (By, error) {
+	if orderBy == "" {
+		return defaultOrder, nil
+	}
+
+	orderParts := strings.Split(orderBy, ",")
+
+	orgFieldName := strings.TrimSpace(orderParts[0])
+	fieldName, exists := fieldMappings[orgFieldName]
+	if !exists {
+		return By{}, fmt.Errorf("unknown order field %q", orgFieldName)
+	}
+
+	switch len(orderParts) {
+	case 1:
+		return NewBy(fieldName, ASC), nil
+
+	case 2:
+		direction := strings.ToUpper(strings.TrimSpace(orderParts[1]))
+		if _, exists := directions[direction]; !exists {
+			return By{}, fmt.Errorf("unknown direction %q", orderParts[1])
+		}
+
+		return NewBy(fieldName, direction), nil
+
+	default:
+		return By{}, fmt.Errorf("unknown order %q", orderBy)
+	}
+}
@@ -0,0 +1,123 @@
+// Package page provides support for query paging.
+package page
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// Defaults applied when the client does not provide paging values.
+const (
+	DefaultNumber      = 1
+	DefaultRowsPerPage = 10
+	MaxRowsPerPage     = 100
+)
+
+// Page represents the requested page and rows per page.
+type Page struct {
+	number int
+	rows   int
+}
+
+// Parse parses the strings and validates the values are in reason.
+func Parse(page string, rowsPerPage string) (Page, error) {
+	number := DefaultNumber
+	if page != "" {
+		var err error
+		number, err = strconv.Atoi(page)
+		if err != nil {
+			return Page{}, fmt.Errorf("page conversion: %w", err)
+		}
+	}
+
+	rows := DefaultRowsPerPage
+	if rowsPerPage != "" {
+		var err error
+		rows, err = strconv.Atoi(rowsPerPage)
+		if err != nil {
+			return Page{}, fmt.Errorf("rows conversion: %w", err)
+		}
+	}
+
+	if number <= 0 {
+		return Page{}, fmt.Errorf("page value too small, must be larger than 0")
+	}
+
+	if rows <= 0 {
+		return Page{}, fmt.Errorf("rows value too small, must be larger than 0")
+	}
+
+	if rows > MaxRowsPerPage {
+		return Page{}, fmt.Errorf("rows value too large, must be at most %d", MaxRowsPerPage)
+	}
+
+	p := Page{
+		number: number,
+		rows:   rows,
+	}
+
+	return p, nil
+}
+
+// MustParse creates a paging value for testing.
+func MustParse(page string, rowsPerPage string) Page {
+	pg, err := Parse(page, rowsPerPage)
+	if err != nil {
+		panic(err)
+	}
+
+	return pg
+}
+
+// String implements the stringer interface.
+func (p Page) String() string {
+	return fmt.Sprintf("page: %d rows: %d", p.number, p.rows)
+}
+
+// Number returns the page number.
+func (p Page) Number() int {
+	return p.number
+}
+
+// RowsPerPage returns the rows per page.
+func (p Page) RowsPerPage() int {
+	return p.rows
+}
+
+// Offset returns the number of rows to skip to reach this page.
+func (p Page) Offset() int {
+	return (p.number - 1) * p.rows
+}
+
+// =============================================================================
+
+// Document is the form used for API responses from query API calls.
+type Document[T any] struct {
+	Items       []T    `json:"items"`
+	Total       int    `json:"total"`
+	Page        int    `json:"page"`
+	RowsPerPage int    `json:"rowsPerPage"`
+	NextCursor  string `json:"nextCursor,omitempty"`
+}
+
+// NewDocument constructs a response value for a web paging response.
+func NewDocument[T any](items []T, total int, pg Page, nextCursor string) Document[T] {
+	if items == nil {
+		items = []T{}
+	}
+
+	return Document[T]{
+		Items:       items,
+		Total:       total,
+		Page:        pg.number,
+		RowsPerPage: pg.rows,
+		NextCursor:  nextCursor,
+	}
+}
+
+// Encode implements the encoder interface.
+func (d Document[T]) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(d)
+	return data, "application/json", err
+}
@@ -0,0 +1,40 @@
+package trace
+
+import (
+	"context"
+	"log/slog"
+)
+
+// LogHandler adds the request ID and trace ID carried by the context of a
+// record to it, so every line logged with a request context can be found by
+// either.
+type LogHandler struct {
+	slog.Handler
+}
+
+// NewLogHandler wraps h in a LogHandler.
+func NewLogHandler(h slog.Handler) *LogHandler {
+	return &LogHandler{Handler: h}
+}
+
+// Handle implements slog.Handler.
+func (h *LogHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if p, ok := ParentFromContext(ctx); ok {
+		r.AddAttrs(slog.String("trace_id", p.TraceIDString()), slog.String("span_id", p.SpanIDString()))
+	}
+
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs implements slog.Handler.
+func (h *LogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &LogHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup implements slog.Handler.
+func (h *LogHandler) WithGroup(name string) slog.Handler {
+	return &LogHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,154 @@
+// Package trace carries the request ID and W3C trace context of a request
+// through its context, so logs, errors and queries can be correlated.
+package trace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// maxRequestIDLen bounds the request IDs accepted from clients.
+const maxRequestIDLen = 128
+
+type requestIDKey struct{}
+
+type parentKey struct{}
+
+// WithRequestID returns a context carrying the request ID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID carried by ctx, or "".
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// NewRequestID returns a random version 4 UUID.
+func NewRequestID() string {
+	var b [16]byte
+	rand.Read(b[:])
+
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// ValidRequestID reports whether a request ID received from a client can be
+// used as is: at most 128 letters, digits and "-_.:", so it is safe to put
+// in headers, logs and SQL comments.
+func ValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case strings.ContainsRune("-_.:", c):
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// -----------------------------------------------------------------------------
+
+// Parent is a W3C trace context, as sent in the traceparent header.
+type Parent struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Flags   byte
+}
+
+// ParseParent parses a traceparent header. Versions after 00 are accepted
+// as long as they start with the fields of version 00.
+func ParseParent(s string) (Parent, error) {
+	var p Parent
+
+	fields := strings.Split(s, "-")
+	if len(fields) < 4 || len(fields[0]) != 2 || len(fields[1]) != 32 || len(fields[2]) != 16 || len(fields[3]) != 2 {
+		return Parent{}, errors.New("traceparent: malformed")
+	}
+
+	version, err := hex.DecodeString(fields[0])
+	if err != nil || version[0] == 0xff || (version[0] == 0 && len(fields) != 4) {
+		return Parent{}, errors.New("traceparent: unsupported version")
+	}
+
+	if strings.ToLower(s) != s {
+		return Parent{}, errors.New("traceparent: must be lowercase")
+	}
+
+	if _, err := hex.Decode(p.TraceID[:], []byte(fields[1])); err != nil || p.TraceID == [16]byte{} {
+		return Parent{}, errors.New("traceparent: invalid trace id")
+	}
+	if _, err := hex.Decode(p.SpanID[:], []byte(fields[2])); err != nil || p.SpanID == [8]byte{} {
+		return Parent{}, errors.New("traceparent: invalid parent id")
+	}
+
+	flags, err := hex.DecodeString(fields[3])
+	if err != nil {
+		return Parent{}, errors.New("traceparent: invalid flags")
+	}
+	p.Flags = flags[0]
+
+	return p, nil
+}
+
+// NewParent starts a new trace with a random trace ID.
+func NewParent() Parent {
+	var p Parent
+	rand.Read(p.TraceID[:])
+	return p.Child()
+}
+
+// Child returns the context of a new span in the same trace, to pass on to
+// the next hop.
+func (p Parent) Child() Parent {
+	rand.Read(p.SpanID[:])
+	return p
+}
+
+// String formats the parent as a version 00 traceparent header.
+func (p Parent) String() string {
+	return fmt.Sprintf("00-%x-%x-%02x", p.TraceID, p.SpanID, p.Flags)
+}
+
+// TraceIDString returns the trace ID in hex, as it appears in logs.
+func (p Parent) TraceIDString() string {
+	return hex.EncodeToString(p.TraceID[:])
+}
+
+// SpanIDString returns the span ID in hex.
+func (p Parent) SpanIDString() string {
+	return hex.EncodeToString(p.SpanID[:])
+}
+
+// WithParent returns a context carrying the trace context.
+func WithParent(ctx context.Context, p Parent) context.Context {
+	return context.WithValue(ctx, parentKey{}, p)
+}
+
+// ParentFromContext returns the trace context carried by ctx.
+func ParentFromContext(ctx context.Context) (Parent, bool) {
+	p, ok := ctx.Value(parentKey{}).(Parent)
+	return p, ok
+}
+
+// TraceID returns the trace ID of the trace context carried by ctx in hex,
+// or "".
+func TraceID(ctx context.Context) string {
+	if p, ok := ParentFromContext(ctx); ok {
+		return p.TraceIDString()
+	}
+	return ""
+}
@@ -0,0 +1,122 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func Test_ParseParent(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		header string
+		err    bool
+	}{
+		{"Valid", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false},
+		{"FutureVersion", "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", false},
+		{"Empty", "", true},
+		{"ExtraFieldsInVersion00", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", true},
+		{"InvalidVersion", "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true},
+		{"Uppercase", "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", true},
+		{"ZeroTraceID", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", true},
+		{"ZeroParentID", "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", true},
+		{"NotHex", "00-4bf92f3577b34da6a3ce929d0e0e473x-00f067aa0ba902b7-01", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p, err := ParseParent(tt.header)
+			if tt.err {
+				if err == nil {
+					t.Fatal("Expected error, got nil")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if p.TraceIDString() != "4bf92f3577b34da6a3ce929d0e0e4736" || p.SpanIDString() != "00f067aa0ba902b7" || p.Flags != 1 {
+				t.Errorf("Expected the header fields, got %s", p)
+			}
+		})
+	}
+}
+
+func Test_ParentChild(t *testing.T) {
+	t.Parallel()
+
+	p, _ := ParseParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	child := p.Child()
+
+	if child.TraceID != p.TraceID || child.Flags != p.Flags {
+		t.Errorf("Expected the child to stay in the trace, got %s", child)
+	}
+	if child.SpanID == p.SpanID {
+		t.Error("Expected the child to get a new span ID")
+	}
+
+	if _, err := ParseParent(NewParent().String()); err != nil {
+		t.Errorf("Expected a new parent to be valid, got %v", err)
+	}
+}
+
+func Test_RequestID(t *testing.T) {
+	t.Parallel()
+
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	if id := NewRequestID(); !uuid.MatchString(id) || !ValidRequestID(id) {
+		t.Errorf("Expected a valid UUID, got %q", id)
+	}
+
+	tests := []struct {
+		id       string
+		expected bool
+	}{
+		{"abc-123_x.y:z", true},
+		{"", false},
+		{"has space", false},
+		{"*/ DROP TABLE todos; /*", false},
+		{strings.Repeat("a", 129), false},
+	}
+
+	for _, tt := range tests {
+		if got := ValidRequestID(tt.id); got != tt.expected {
+			t.Errorf("Expected %q valid to be %v, got %v", tt.id, tt.expected, got)
+		}
+	}
+}
+
+func Test_LogHandler(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	log := slog.New(NewLogHandler(slog.NewTextHandler(&buf, nil))).With("app", "todo")
+
+	p, _ := ParseParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx := WithParent(WithRequestID(context.Background(), "abc"), p)
+
+	log.InfoContext(ctx, "hello")
+	log.Info("no request")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %q", buf.String())
+	}
+
+	for _, attr := range []string{"app=todo", "request_id=abc", "trace_id=4bf92f3577b34da6a3ce929d0e0e4736", "span_id=00f067aa0ba902b7"} {
+		if !strings.Contains(lines[0], attr) {
+			t.Errorf("Expected %s in %q", attr, lines[0])
+		}
+	}
+	if strings.Contains(lines[1], "request_id") {
+		t.Errorf("Expected no request ID without a request context, got %q", lines[1])
+	}
+}
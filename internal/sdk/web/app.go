@@ -0,0 +1,70 @@
+package web
+
+import (
+	"log/slog"
+	"net/http"
+)
+
+// Middleware is a function that wraps an http.Handler.
+type Middleware func(http.Handler) http.Handler
+
+// WrapMiddleware wraps h in the middlewares. The first middleware in the
+// slice is the outermost, so it is the first to see the request. Nil
+// middlewares are skipped.
+func WrapMiddleware(h http.Handler, middlewares ...Middleware) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		if middlewares[i] != nil {
+			h = middlewares[i](h)
+		}
+	}
+	return h
+}
+
+// App registers the routes of one app package on a shared mux. Its
+// middleware wraps every route registered through it, around any
+// middleware given for the route itself:
+//
+//	global (around the mux) -> app -> route -> handler
+//
+// App and route middleware run after the mux has matched the request, so
+// r.Pattern and r.PathValue are available to them. Middleware that must see
+// every request, including those no route matches, belongs around the mux.
+type App struct {
+	log *slog.Logger
+	mux *http.ServeMux
+	mw  []Middleware
+}
+
+// NewApp returns an App registering its routes on mux with mw applied to
+// each of them. Errors from its handlers are logged to log, or to
+// slog.Default when log is nil.
+func NewApp(log *slog.Logger, mux *http.ServeMux, mw ...Middleware) *App {
+	if log == nil {
+		log = slog.Default()
+	}
+
+	return &App{
+		log: log,
+		mux: mux,
+		mw:  mw,
+	}
+}
+
+// Handle registers handler for pattern, wrapped in mw and then in the app
+// middleware.
+func (a *App) Handle(pattern string, handler http.Handler, mw ...Middleware) {
+	handler = WrapMiddleware(handler, mw...)
+	handler = WrapMiddleware(handler, a.mw...)
+
+	a.mux.Handle(pattern, handler)
+}
+
+// HandleFunc registers fn for pattern like Handle. Its errors, and failures
+// to write its response, are logged to the app's logger.
+func (a *App) HandleFunc(pattern string, fn HandlerFunc, mw ...Middleware) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fn.serve(a.log, w, r)
+	})
+
+	a.Handle(pattern, h, mw...)
+}
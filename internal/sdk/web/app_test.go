@@ -0,0 +1,83 @@
+package web
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// record returns a middleware that records its name, and the pattern the
+// request matched, in calls.
+func record(name string, calls *[]string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*calls = append(*calls, name+":"+r.Pattern)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func Test_App(t *testing.T) {
+	t.Parallel()
+
+	var calls []string
+
+	mux := http.NewServeMux()
+	app := NewApp(nil, mux, record("app1", &calls), nil, record("app2", &calls))
+
+	app.HandleFunc("GET /todo/{id}", func(w http.ResponseWriter, r *http.Request) (Encoder, error) {
+		calls = append(calls, "handler:"+r.PathValue("id"))
+		return nil, nil
+	}, record("route", &calls))
+	app.HandleFunc("GET /plain", func(w http.ResponseWriter, r *http.Request) (Encoder, error) {
+		calls = append(calls, "plain")
+		return nil, nil
+	})
+
+	h := WrapMiddleware(mux, record("global", &calls))
+
+	tests := []struct {
+		target   string
+		expected string
+	}{
+		{"/todo/7", "global:,app1:GET /todo/{id},app2:GET /todo/{id},route:GET /todo/{id},handler:7"},
+		{"/plain", "global:,app1:GET /plain,app2:GET /plain,plain"},
+		{"/missing", "global:"},
+	}
+
+	for _, tt := range tests {
+		calls = nil
+
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+		if got := strings.Join(calls, ","); got != tt.expected {
+			t.Errorf("Expected %s to run %q, got %q", tt.target, tt.expected, got)
+		}
+	}
+}
+
+func Test_AppLogger(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	mux := http.NewServeMux()
+	app := NewApp(slog.New(slog.NewTextHandler(&buf, nil)), mux)
+	app.HandleFunc("GET /fail", func(w http.ResponseWriter, r *http.Request) (Encoder, error) {
+		return nil, errors.New("boom")
+	})
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fail", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+	if !strings.Contains(buf.String(), "boom") {
+		t.Errorf("Expected the error in the app log, got %q", buf.String())
+	}
+}
@@ -0,0 +1,57 @@
+package web
+
+import (
+	"net/http"
+	"strings"
+)
+
+// IfMatch reports whether the If-Match precondition of the request holds for
+// the current entity tag. A request without the header always holds. As
+// required by RFC 9110, weak tags never match.
+func IfMatch(r *http.Request, etag string) bool {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return true
+	}
+
+	return matchETag(header, etag, false)
+}
+
+// IfNoneMatch reports whether the If-None-Match header of the request matches
+// the current entity tag, meaning the client already has this version. Weak
+// comparison is used.
+func IfNoneMatch(r *http.Request, etag string) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+
+	return matchETag(header, etag, true)
+}
+
+func matchETag(header string, etag string, weak bool) bool {
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+
+		if weak {
+			if strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+			continue
+		}
+
+		if strings.HasPrefix(candidate, "W/") || strings.HasPrefix(etag, "W/") {
+			continue
+		}
+
+		if candidate == etag {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,83 @@
+package web
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/BuildFrom/Golang-Stdlib/internal/sdk/errs"
+)
+
+// MaxBodyBytes is the largest request body ReadBody and Decode will read.
+const MaxBodyBytes = 1 << 20
+
+type validator interface {
+	Validate() error
+}
+
+// Decode reads the body of an HTTP request and decodes it into the
+// specified data model. If the data model implements the validator
+// interface, the method will be executed. Failures are returned as
+// InvalidArgument errors, or PayloadTooLarge when the body is over
+// MaxBodyBytes.
+func Decode(r *http.Request, v Decoder) error {
+	data, err := ReadBody(r)
+	if err != nil {
+		return err
+	}
+
+	if err := v.Decode(data); err != nil {
+		return errs.Newf(errs.InvalidArgument, "request: decode: %s", err)
+	}
+
+	if v, ok := v.(validator); ok {
+		if err := v.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ReadBody reads the body of an HTTP request, up to MaxBodyBytes. A larger
+// body is a PayloadTooLarge error and other read failures are
+// InvalidArgument errors.
+func ReadBody(r *http.Request) ([]byte, error) {
+	data, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, MaxBodyBytes))
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return nil, errs.Newf(errs.PayloadTooLarge, "request: payload exceeds %d bytes", maxErr.Limit)
+		}
+		return nil, errs.Newf(errs.InvalidArgument, "request: unable to read payload: %s", err)
+	}
+
+	return data, nil
+}
+
+// Param returns the path value for the given key, or an InvalidArgument
+// error when it is missing.
+func Param(r *http.Request, key string) (string, error) {
+	v := r.PathValue(key)
+	if v == "" {
+		return "", errs.Newf(errs.InvalidArgument, "request: missing path parameter %q", key)
+	}
+
+	return v, nil
+}
+
+// ParamInt returns the path value for the given key parsed as an int.
+func ParamInt(r *http.Request, key string) (int, error) {
+	v, err := Param(r, key)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, errs.Newf(errs.InvalidArgument, "request: invalid %s format", key)
+	}
+
+	return n, nil
+}
@@ -0,0 +1,120 @@
+package web
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/BuildFrom/Golang-Stdlib/internal/sdk/errs"
+	"github.com/BuildFrom/Golang-Stdlib/internal/sdk/trace"
+)
+
+// httpStatus is implemented by values that know which http status they
+// should be written with.
+type httpStatus interface {
+	HTTPStatus() int
+}
+
+// Response pairs an Encoder with the http status it should be written with.
+// A nil Data value writes the status with no body.
+type Response struct {
+	Status int
+	Data   Encoder
+}
+
+// NewResponse constructs a Response for the provided status and data.
+func NewResponse(status int, data Encoder) Response {
+	return Response{
+		Status: status,
+		Data:   data,
+	}
+}
+
+// Encode implements the encoder interface.
+func (r Response) Encode() ([]byte, string, error) {
+	if r.Data == nil {
+		return nil, "", nil
+	}
+	return r.Data.Encode()
+}
+
+// HTTPStatus implements the httpStatus interface.
+func (r Response) HTTPStatus() int {
+	return r.Status
+}
+
+// =============================================================================
+
+// Respond sends the encoded data to the client. A nil value results in a
+// 204 No Content. The status defaults to 200 OK unless the value implements
+// HTTPStatus. Write failures are logged to log with ctx, so they carry the
+// request ID.
+func Respond(ctx context.Context, log *slog.Logger, w http.ResponseWriter, resp Encoder) {
+	if resp == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	statusCode := http.StatusOK
+	if v, ok := resp.(httpStatus); ok {
+		statusCode = v.HTTPStatus()
+	}
+
+	data, contentType, err := resp.Encode()
+	if err != nil {
+		RespondError(ctx, log, w, errs.Newf(errs.Internal, "web: encode response: %s", err))
+		return
+	}
+
+	if len(data) == 0 {
+		w.WriteHeader(statusCode)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(statusCode)
+
+	if _, err := w.Write(data); err != nil {
+		log.ErrorContext(ctx, "web: error writing response", "error", err)
+	}
+}
+
+// RespondError converts the error into an errs.Error and sends it to the
+// client as JSON using the status mapped from the error code, with the
+// request and trace IDs carried by ctx. Internal errors are logged to log.
+func RespondError(ctx context.Context, log *slog.Logger, w http.ResponseWriter, err error) {
+	appErr := errs.NewError(err)
+
+	if appErr.Code == errs.Internal || appErr.Code == errs.InternalOnlyLog {
+		log.ErrorContext(ctx, "web: internal error", "file", appErr.FileName, "func", appErr.FuncName, "error", appErr)
+	}
+
+	// Internal only errors are logged but never sent to the client.
+	if appErr.Code == errs.InternalOnlyLog {
+		appErr = errs.Newf(errs.InternalOnlyLog, http.StatusText(http.StatusInternalServerError))
+	}
+
+	// The request and trace IDs let a client quote the error it got. The
+	// error may be shared, so the copy is changed.
+	requestID, traceID := trace.RequestID(ctx), trace.TraceID(ctx)
+	if requestID != "" || traceID != "" {
+		e := *appErr
+		e.RequestID = requestID
+		e.TraceID = traceID
+		appErr = &e
+	}
+
+	data, contentType, encErr := appErr.Encode()
+	if encErr != nil {
+		log.ErrorContext(ctx, "web: error encoding error response", "error", encErr)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(appErr.HTTPStatus())
+
+	if _, err := w.Write(data); err != nil {
+		log.ErrorContext(ctx, "web: error writing response", "error", err)
+	}
+}
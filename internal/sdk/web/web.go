@@ -0,0 +1,41 @@
+// Package web provides a thin layer over net/http so app handlers can return
+// values and errors instead of writing responses by hand.
+package web
+
+import (
+	"log/slog"
+	"net/http"
+)
+
+// Encoder defines behavior that can encode a data model and provide
+// the content type for that encoding.
+type Encoder interface {
+	Encode() (data []byte, contentType string, err error)
+}
+
+// Decoder represents data that can be decoded.
+type Decoder interface {
+	Decode(data []byte) error
+}
+
+// HandlerFunc represents a function that handles a http request and returns
+// the value to encode as the response, or an error.
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) (Encoder, error)
+
+// ServeHTTP implements the http.Handler interface. The returned value is
+// written with Respond and any error is converted into an errs.Error.
+// Handlers registered through an App log to the App's logger instead of
+// slog.Default.
+func (f HandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.serve(slog.Default(), w, r)
+}
+
+func (f HandlerFunc) serve(log *slog.Logger, w http.ResponseWriter, r *http.Request) {
+	resp, err := f(w, r)
+	if err != nil {
+		RespondError(r.Context(), log, w, err)
+		return
+	}
+
+	Respond(r.Context(), log, w, resp)
+}
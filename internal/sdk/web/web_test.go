@@ -0,0 +1,137 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/BuildFrom/Golang-Stdlib/internal/sdk/errs"
+	"github.com/BuildFrom/Golang-Stdlib/internal/sdk/trace"
+)
+
+type testData struct {
+	Name string `json:"name"`
+}
+
+func (d testData) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(d)
+	return data, "application/json", err
+}
+
+func Test_HandlerFunc(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		handler     HandlerFunc
+		status      int
+		contentType string
+		code        string
+	}{
+		{
+			name: "Encoder",
+			handler: func(w http.ResponseWriter, r *http.Request) (Encoder, error) {
+				return testData{Name: "todo"}, nil
+			},
+			status:      http.StatusOK,
+			contentType: "application/json",
+		},
+		{
+			name: "NoContent",
+			handler: func(w http.ResponseWriter, r *http.Request) (Encoder, error) {
+				return nil, nil
+			},
+			status: http.StatusNoContent,
+		},
+		{
+			name: "Response",
+			handler: func(w http.ResponseWriter, r *http.Request) (Encoder, error) {
+				return NewResponse(http.StatusCreated, testData{Name: "todo"}), nil
+			},
+			status:      http.StatusCreated,
+			contentType: "application/json",
+		},
+		{
+			name: "ErrsError",
+			handler: func(w http.ResponseWriter, r *http.Request) (Encoder, error) {
+				return nil, errs.Newf(errs.NotFound, "todo not found")
+			},
+			status:      http.StatusNotFound,
+			contentType: "application/json",
+			code:        "not_found",
+		},
+		{
+			name: "PlainError",
+			handler: func(w http.ResponseWriter, r *http.Request) (Encoder, error) {
+				return nil, errors.New("boom")
+			},
+			status:      http.StatusInternalServerError,
+			contentType: "application/json",
+			code:        "internal",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			tt.handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if w.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, w.Code)
+			}
+			if got := w.Header().Get("Content-Type"); got != tt.contentType {
+				t.Errorf("Expected content type %q, got %q", tt.contentType, got)
+			}
+
+			if tt.code == "" {
+				return
+			}
+
+			var body struct {
+				Code string `json:"code"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("Expected JSON error body, got %v", err)
+			}
+			if body.Code != tt.code {
+				t.Errorf("Expected code %q, got %q", tt.code, body.Code)
+			}
+		})
+	}
+}
+
+func Test_RespondErrorRequestID(t *testing.T) {
+	t.Parallel()
+
+	shared := errs.Newf(errs.NotFound, "todo not found")
+
+	handler := HandlerFunc(func(w http.ResponseWriter, r *http.Request) (Encoder, error) {
+		return nil, shared
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	parent := trace.NewParent()
+	r = r.WithContext(trace.WithParent(trace.WithRequestID(r.Context(), "abc"), parent))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	var body struct {
+		RequestID string `json:"request_id"`
+		TraceID   string `json:"trace_id"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Expected JSON error body, got %v", err)
+	}
+	if body.RequestID != "abc" {
+		t.Errorf("Expected request ID %q, got %q", "abc", body.RequestID)
+	}
+	if body.TraceID != parent.TraceIDString() {
+		t.Errorf("Expected trace ID %q, got %q", parent.TraceIDString(), body.TraceID)
+	}
+	if shared.RequestID != "" || shared.TraceID != "" {
+		t.Error("Expected the returned error to be left unchanged")
+	}
+}